@@ -0,0 +1,28 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/mokiat/gocrane/internal/pipeline"
+)
+
+const (
+	analysisOff   = "off"
+	analysisWarn  = "warn"
+	analysisBlock = "block"
+)
+
+// parseAnalysisMode returns the analysis mode that corresponds to the
+// specified flag value or an empty mode if analysis is disabled.
+func parseAnalysisMode(value string) (pipeline.AnalysisMode, error) {
+	switch value {
+	case analysisOff:
+		return "", nil
+	case analysisWarn:
+		return pipeline.AnalysisModeWarn, nil
+	case analysisBlock:
+		return pipeline.AnalysisModeBlock, nil
+	default:
+		return "", fmt.Errorf("unknown analysis mode %q", value)
+	}
+}
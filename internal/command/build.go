@@ -3,11 +3,12 @@ package command
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/urfave/cli/v2"
 
 	"github.com/mokiat/gocrane/internal/command/flag"
+	"github.com/mokiat/gocrane/internal/filesystem"
 	"github.com/mokiat/gocrane/internal/project"
 )
 
@@ -17,6 +18,8 @@ func Build() *cli.Command {
 		Name: "build",
 		Flags: []cli.Flag{
 			newVerboseFlag(&cfg.Verbose),
+			newLogLevelFlag(&cfg.LogLevel),
+			newLogFormatFlag(&cfg.LogFormat),
 			newDirFlag(&cfg.Dirs),
 			newDirExcludeFlag(&cfg.ExcludeDirs),
 			newSourceFlag(&cfg.Sources),
@@ -24,8 +27,13 @@ func Build() *cli.Command {
 			newResourceFlag(&cfg.Resources),
 			newResourceExcludeFlag(&cfg.ExcludeResources),
 			newMainFlag(&cfg.MainDir),
+			newLocalModulesFlag(&cfg.LocalModules),
+			newFollowSymlinksFlag(&cfg.FollowSymlinks),
 			newBinaryFlag(&cfg.BinaryFile, true),
+			newDirCacheFlag(&cfg.DirCache),
 			newBuildArgs(&cfg.BuildArgs),
+			newBuildEnvFlag(&cfg.BuildEnv),
+			newBuildEnvFileFlag(&cfg.BuildEnvFiles),
 		},
 		Action: func(c *cli.Context) error {
 			return build(c.Context, cfg)
@@ -35,6 +43,8 @@ func Build() *cli.Command {
 
 type buildConfig struct {
 	Verbose          bool
+	LogLevel         string
+	LogFormat        string
 	Dirs             cli.StringSlice
 	ExcludeDirs      cli.StringSlice
 	Sources          cli.StringSlice
@@ -42,18 +52,32 @@ type buildConfig struct {
 	Resources        cli.StringSlice
 	ExcludeResources cli.StringSlice
 	MainDir          string
+	LocalModules     bool
+	FollowSymlinks   bool
 	BinaryFile       string
+	DirCache         bool
 	BuildArgs        flag.ShlexStringSlice
+	BuildEnv         cli.StringSlice
+	BuildEnvFiles    cli.StringSlice
 }
 
 func build(ctx context.Context, cfg buildConfig) error {
-	log.Println("Building binary...")
-	builder := project.NewBuilder(cfg.MainDir, cfg.BuildArgs.Value())
+	if _, err := setupLogging(cfg.Verbose, cfg.LogLevel, cfg.LogFormat); err != nil {
+		return err
+	}
+	verbose := slog.Default().Enabled(ctx, slog.LevelDebug)
+	if err := validateEnv(cfg.BuildEnv.Value()); err != nil {
+		return err
+	}
+	buildEnv := project.NewEnvironment(cfg.BuildEnv.Value(), cfg.BuildEnvFiles.Value())
+
+	slog.Info("Building binary...", "path", cfg.BinaryFile)
+	builder := project.NewBuilder(cfg.MainDir, cfg.BuildArgs.Value(), buildEnv)
 	if err := builder.Build(ctx, cfg.BinaryFile); err != nil {
 		return fmt.Errorf("failed to build binary: %w", err)
 	}
 
-	log.Println("Preparing filtering...")
+	slog.Info("Preparing filtering...")
 	watchFilter, err := buildFilterTree(cfg.Dirs.Value(), cfg.ExcludeDirs.Value())
 	if err != nil {
 		return fmt.Errorf("problem with dir rules: %w", err)
@@ -66,31 +90,44 @@ func build(ctx context.Context, cfg buildConfig) error {
 	if err != nil {
 		return fmt.Errorf("problem with resource rules: %w", err)
 	}
+	if err := watchEnvFiles(cfg.BuildEnvFiles.Value(), watchFilter, sourceFilter); err != nil {
+		return err
+	}
+	if cfg.LocalModules {
+		watchLocalModules(cfg.MainDir, watchFilter, sourceFilter)
+	}
 	rootDirs := watchFilter.RootPaths()
 
 	var summary *project.Summary
-	if cfg.Verbose || cfg.BinaryFile != "" {
-		log.Println("Analyzing project...")
-		summary = project.Analyze(rootDirs, watchFilter, sourceFilter, resourceFilter)
+	if verbose || cfg.BinaryFile != "" {
+		slog.Info("Analyzing project...")
+		var dirCache *filesystem.DirCache
+		if cfg.DirCache {
+			dirCache = loadDirCache(cfg.BinaryFile)
+		}
+		summary = project.Analyze(rootDirs, watchFilter, sourceFilter, resourceFilter, cfg.FollowSymlinks, dirCache)
+		if dirCache != nil {
+			saveDirCache(cfg.BinaryFile, dirCache)
+		}
 	}
-	if cfg.Verbose {
-		printSummary(summary)
+	if verbose {
+		printSummary(summary, buildEnv)
 	}
 
-	log.Println("Calculating current digest...")
-	digest, err := calculateDigest(summary)
+	slog.Info("Calculating current digest...")
+	digest, err := calculateDigest(summary, buildEnv)
 	if err != nil {
 		return fmt.Errorf("failed to calculate digest: %w", err)
 	}
-	log.Printf("Digest: %s", digest)
+	slog.Info("Calculated digest.", "digest", digest)
 
-	log.Println("Persisting digest...")
+	slog.Info("Persisting digest...")
 	digestFile := fmt.Sprintf("%s.dig", cfg.BinaryFile)
 	if err := project.SaveDigestFile(digestFile, digest); err != nil {
 		return fmt.Errorf("failed to write digest: %w", err)
 	}
-	log.Println("Digest successfully persisted.")
+	slog.Info("Digest successfully persisted.", "path", digestFile)
 
-	log.Println("Done.")
+	slog.Info("Done.")
 	return nil
 }
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+// dirCacheFile returns the file where the folder listings of the project
+// are cached, next to the binary and its digest file.
+func dirCacheFile(binaryFile string) string {
+	return fmt.Sprintf("%s.dirs", binaryFile)
+}
+
+// loadDirCache reads the folder listings that were cached for the specified
+// binary. An empty cache is returned if they cannot be read, in which case
+// all folders are listed anew.
+func loadDirCache(binaryFile string) *filesystem.DirCache {
+	path := dirCacheFile(binaryFile)
+	cache, err := filesystem.LoadDirCache(path)
+	switch {
+	case err == nil:
+		slog.Debug("Loaded folder cache.", "path", path)
+		return cache
+	case errors.Is(err, fs.ErrNotExist):
+		slog.Debug("No folder cache found.", "path", path)
+	default:
+		slog.Warn("Failed to load folder cache, will list all folders.", "path", path, "error", err)
+	}
+	return filesystem.NewDirCache()
+}
+
+// saveDirCache persists the folder listings of the last analysis for the
+// specified binary. Failing to do so only affects the startup time of the
+// next run, so it is not treated as an error.
+func saveDirCache(binaryFile string, cache *filesystem.DirCache) {
+	reused, read := cache.Stats()
+	slog.Debug("Listed folders.", "reused", reused, "read", read)
+
+	path := dirCacheFile(binaryFile)
+	if err := cache.Save(path); err != nil {
+		slog.Warn("Failed to save folder cache.", "path", path, "error", err)
+	}
+}
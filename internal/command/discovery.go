@@ -0,0 +1,28 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/mokiat/gocrane/internal/pipeline"
+)
+
+const (
+	discoveryOff     = "off"
+	discoveryPropose = "propose"
+	discoveryAuto    = "auto"
+)
+
+// parseDiscoveryMode returns the discovery mode that corresponds to the
+// specified flag value or an empty mode if discovery is disabled.
+func parseDiscoveryMode(value string) (pipeline.DiscoveryMode, error) {
+	switch value {
+	case discoveryOff:
+		return "", nil
+	case discoveryPropose:
+		return pipeline.DiscoveryModePropose, nil
+	case discoveryAuto:
+		return pipeline.DiscoveryModeAuto, nil
+	default:
+		return "", fmt.Errorf("unknown discovery mode %q", value)
+	}
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+// validateEnv checks that the entries of an environment flag are in
+// KEY=VALUE form.
+func validateEnv(entries []string) error {
+	for _, entry := range entries {
+		if !strings.Contains(entry, "=") {
+			return fmt.Errorf("environment variable %q is not in KEY=VALUE form", entry)
+		}
+	}
+	return nil
+}
+
+// watchEnvFiles makes sure that the specified environment files are watched
+// and accepted by the specified filter, so that changing them triggers the
+// corresponding action. Environment files are read anew on each build or
+// start.
+func watchEnvFiles(files []string, watchFilter, filter *filesystem.FilterTree) error {
+	for _, file := range files {
+		absFile, err := filesystem.ToAbsolutePath(file)
+		if err != nil {
+			return fmt.Errorf("error converting path %q to absolute: %w", file, err)
+		}
+		watchFilter.AcceptPath(absFile)
+		filter.AcceptPath(absFile)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+func Explain() *cli.Command {
+	var cfg explainConfig
+	return &cli.Command{
+		Name:      "explain",
+		Usage:     "explain how the filtering rules affect the specified paths",
+		ArgsUsage: "<path> [<path>...]",
+		Flags: []cli.Flag{
+			newDirFlag(&cfg.Dirs),
+			newDirExcludeFlag(&cfg.ExcludeDirs),
+			newSourceFlag(&cfg.Sources),
+			newSourceExcludeFlag(&cfg.ExcludeSources),
+			newResourceFlag(&cfg.Resources),
+			newResourceExcludeFlag(&cfg.ExcludeResources),
+			newIgnoreFlag(&cfg.Ignores),
+			newMainFlag(&cfg.MainDir),
+			newLocalModulesFlag(&cfg.LocalModules),
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("at least one path needs to be specified")
+			}
+			return explain(c.Context, cfg, c.Args().Slice())
+		},
+	}
+}
+
+type explainConfig struct {
+	Dirs             cli.StringSlice
+	ExcludeDirs      cli.StringSlice
+	Sources          cli.StringSlice
+	ExcludeSources   cli.StringSlice
+	Resources        cli.StringSlice
+	ExcludeResources cli.StringSlice
+	Ignores          cli.StringSlice
+	MainDir          string
+	LocalModules     bool
+}
+
+func explain(_ context.Context, cfg explainConfig, paths []string) error {
+	watchFilter, err := buildFilterTree(cfg.Dirs.Value(), cfg.ExcludeDirs.Value())
+	if err != nil {
+		return fmt.Errorf("problem with dir rules: %w", err)
+	}
+	sourceFilter, err := buildFilterTree(cfg.Sources.Value(), cfg.ExcludeSources.Value())
+	if err != nil {
+		return fmt.Errorf("problem with source rules: %w", err)
+	}
+	resourceFilter, err := buildFilterTree(cfg.Resources.Value(), cfg.ExcludeResources.Value())
+	if err != nil {
+		return fmt.Errorf("problem with resource rules: %w", err)
+	}
+	ignoreFilter, err := buildFilterTree(cfg.Ignores.Value(), nil)
+	if err != nil {
+		return fmt.Errorf("problem with ignore rules: %w", err)
+	}
+
+	if cfg.LocalModules {
+		watchLocalModules(cfg.MainDir, watchFilter, sourceFilter)
+	}
+
+	for _, path := range paths {
+		absPath, err := filesystem.ToAbsolutePath(path)
+		if err != nil {
+			return fmt.Errorf("error converting path %q to absolute: %w", path, err)
+		}
+		watchDecision := watchFilter.Explain(absPath)
+		sourceDecision := sourceFilter.Explain(absPath)
+		resourceDecision := resourceFilter.Explain(absPath)
+		ignoreDecision := ignoreFilter.Explain(absPath)
+
+		fmt.Println(absPath)
+		fmt.Printf("\t watch:    %s\n", describeDecision(watchDecision))
+		fmt.Printf("\t source:   %s\n", describeDecision(sourceDecision))
+		fmt.Printf("\t resource: %s\n", describeDecision(resourceDecision))
+		fmt.Printf("\t ignore:   %s\n", describeDecision(ignoreDecision))
+		fmt.Printf("\t outcome:  %s\n", describeOutcome(absPath, watchDecision, sourceDecision, resourceDecision, ignoreDecision))
+	}
+	return nil
+}
+
+func describeDecision(decision filesystem.FilterDecision) string {
+	verdict := "rejected"
+	if decision.Accepted {
+		verdict = "accepted"
+	}
+	switch {
+	case decision.Rule == nil:
+		return fmt.Sprintf("%s (no matching rule)", verdict)
+	case decision.Rule.IsGlob():
+		return fmt.Sprintf("%s by glob rule %q (at %q)", verdict, decision.Rule, decision.Path)
+	default:
+		return fmt.Sprintf("%s by path rule %q", verdict, decision.Rule)
+	}
+}
+
+func describeOutcome(path filesystem.AbsolutePath, watchDecision, sourceDecision, resourceDecision, ignoreDecision filesystem.FilterDecision) string {
+	if !watchDecision.Accepted {
+		return "ignored (not watched)"
+	}
+	if ignoreDecision.Accepted {
+		return "ignored (temporary file)"
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return "watched"
+	}
+	switch {
+	case sourceDecision.Accepted:
+		return "rebuild"
+	case resourceDecision.Accepted:
+		return "restart"
+	default:
+		return "ignored (neither source nor resource)"
+	}
+}
@@ -0,0 +1,7 @@
+package command
+
+var BuildFilterTree = buildFilterTree
+
+var WatchLocalModules = watchLocalModules
+
+var UsePseudoTerminal = usePseudoTerminal
@@ -6,28 +6,42 @@ import (
 	"github.com/mokiat/gocrane/internal/filesystem"
 )
 
+// buildFilterTree creates a FilterTree out of the entries of an accepting
+// flag (e.g. `source`) and its corresponding excluding flag
+// (e.g. `source-exclude`).
+//
+// Accepted entries form an ordered, last-match-wins list, where entries
+// prefixed with `!` are rejecting. Excluded paths are placed before them, so
+// that accepted entries can override them, and excluded globs are placed
+// after them, so that they override all accepted entries. This keeps the
+// precedence that was used before rules became ordered.
 func buildFilterTree(accepted, rejected []string) (*filesystem.FilterTree, error) {
+	rejectedRules := make([]filesystem.FilterRule, len(rejected))
+	for i, entry := range rejected {
+		rule, err := filesystem.ParseFilterRule(entry)
+		if err != nil {
+			return nil, fmt.Errorf("error processing reject rule: %w", err)
+		}
+		rule.Accept = !rule.Accept
+		rejectedRules[i] = rule
+	}
+
 	result := filesystem.NewFilterTree()
+	for _, rule := range rejectedRules {
+		if !rule.IsGlob() {
+			result.AddRule(rule)
+		}
+	}
 	for _, entry := range accepted {
-		if filesystem.IsGlob(entry) {
-			result.AcceptGlob(entry)
-		} else {
-			path, err := filesystem.ToAbsolutePath(entry)
-			if err != nil {
-				return nil, fmt.Errorf("error processing accept rule: %w", err)
-			}
-			result.AcceptPath(path)
+		rule, err := filesystem.ParseFilterRule(entry)
+		if err != nil {
+			return nil, fmt.Errorf("error processing accept rule: %w", err)
 		}
+		result.AddRule(rule)
 	}
-	for _, entry := range rejected {
-		if filesystem.IsGlob(entry) {
-			result.RejectGlob(entry)
-		} else {
-			path, err := filesystem.ToAbsolutePath(entry)
-			if err != nil {
-				return nil, fmt.Errorf("error processing reject rule: %w", err)
-			}
-			result.RejectPath(path)
+	for _, rule := range rejectedRules {
+		if rule.IsGlob() {
+			result.AddRule(rule)
 		}
 	}
 	return result, nil
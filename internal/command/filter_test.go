@@ -0,0 +1,48 @@
+package command_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/command"
+)
+
+var _ = Describe("BuildFilterTree", func() {
+	It("lets excluded globs override accepted rules", func() {
+		tree, err := command.BuildFilterTree(
+			[]string{"*/*.go", "/project/internal/x/*/*_test.go"},
+			[]string{"*/*_test.go", "*/*_gen.go"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree.IsAccepted("/project/main.go")).To(BeTrue())
+		Expect(tree.IsAccepted("/project/main_test.go")).To(BeFalse())
+		Expect(tree.IsAccepted("/project/main_gen.go")).To(BeFalse())
+		Expect(tree.IsAccepted("/project/internal/x/x_test.go")).To(BeFalse())
+	})
+
+	It("lets accepted rules override excluded paths", func() {
+		tree, err := command.BuildFilterTree(
+			[]string{"*/*.md", "/project/docs/README.md"},
+			[]string{"/project/docs"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree.IsAccepted("/project/README.md")).To(BeTrue())
+		Expect(tree.IsAccepted("/project/docs/README.md")).To(BeTrue())
+	})
+
+	It("applies negated rules in order", func() {
+		tree, err := command.BuildFilterTree(
+			[]string{"*/*.go", "!*/*_gen.go", "/project/internal/x/*/*_gen.go"},
+			nil,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree.IsAccepted("/project/main.go")).To(BeTrue())
+		Expect(tree.IsAccepted("/project/main_gen.go")).To(BeFalse())
+		Expect(tree.IsAccepted("/project/internal/x/x_gen.go")).To(BeTrue())
+	})
+
+	It("reports invalid rules", func() {
+		_, err := command.BuildFilterTree([]string{"*/a/b"}, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
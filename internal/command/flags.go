@@ -6,13 +6,14 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/mokiat/gocrane/internal/command/flag"
+	"github.com/mokiat/gocrane/internal/control"
 	"github.com/mokiat/gocrane/internal/filesystem"
 )
 
 func newVerboseFlag(target *bool) cli.Flag {
 	return &cli.BoolFlag{
 		Name:        "verbose",
-		Usage:       "verbose logging",
+		Usage:       "verbose logging (same as debug log level)",
 		Aliases:     []string{"v"},
 		EnvVars:     []string{"GOCRANE_VERBOSE"},
 		Value:       false,
@@ -20,10 +21,32 @@ func newVerboseFlag(target *bool) cli.Flag {
 	}
 }
 
+func newLogLevelFlag(target *string) cli.Flag {
+	return &cli.StringFlag{
+		Name:        "log-level",
+		Usage:       "minimum level of logged events (debug, info, warn, error)",
+		Aliases:     []string{"ll"},
+		EnvVars:     []string{"GOCRANE_LOG_LEVEL"},
+		Value:       "info",
+		Destination: target,
+	}
+}
+
+func newLogFormatFlag(target *string) cli.Flag {
+	return &cli.StringFlag{
+		Name:        "log-format",
+		Usage:       "format of logged events (text, json)",
+		Aliases:     []string{"lf"},
+		EnvVars:     []string{"GOCRANE_LOG_FORMAT"},
+		Value:       "text",
+		Destination: target,
+	}
+}
+
 func newDirFlag(target *cli.StringSlice) cli.Flag {
 	return &cli.StringSliceFlag{
 		Name:    "dir",
-		Usage:   "folder(s) that should be watched",
+		Usage:   "ordered folder rule(s) for watching, where a leading ! negates a rule",
 		Aliases: []string{"d"},
 		EnvVars: []string{"GOCRANE_DIRS"},
 		Value: cli.NewStringSlice(
@@ -52,7 +75,7 @@ func newDirExcludeFlag(target *cli.StringSlice) cli.Flag {
 func newSourceFlag(target *cli.StringSlice) cli.Flag {
 	return &cli.StringSliceFlag{
 		Name:    "source",
-		Usage:   "filter(s) that indicate which watched files should trigger a build",
+		Usage:   "ordered filter rule(s) that indicate which watched files should trigger a build, where a leading ! negates a rule",
 		Aliases: []string{"s"},
 		EnvVars: []string{"GOCRANE_SOURCES"},
 		Value: cli.NewStringSlice(
@@ -80,7 +103,7 @@ func newSourceExcludeFlag(target *cli.StringSlice) cli.Flag {
 func newResourceFlag(target *cli.StringSlice) cli.Flag {
 	return &cli.StringSliceFlag{
 		Name:        "resource",
-		Usage:       "filter(s) that indicate which watched files should trigger a restart",
+		Usage:       "ordered filter rule(s) that indicate which watched files should trigger a restart, where a leading ! negates a rule",
 		Aliases:     []string{"r"},
 		EnvVars:     []string{"GOCRANE_RESOURCES"},
 		Value:       cli.NewStringSlice(),
@@ -107,6 +130,45 @@ func newResourceExcludeFlag(target *cli.StringSlice) cli.Flag {
 	}
 }
 
+func newIgnoreFlag(target *cli.StringSlice) cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:    "ignore",
+		Usage:   "filter(s) for temporary files of editors and tools, whose changes should be ignored",
+		EnvVars: []string{"GOCRANE_IGNORES"},
+		Value: cli.NewStringSlice(
+			filesystem.Glob("*.swp"),         // Vim swap files
+			filesystem.Glob("*.swx"),         // Vim swap files
+			filesystem.Glob("4913"),          // Vim probe files
+			filesystem.Glob("*~"),            // Vim and Emacs backup files
+			filesystem.Glob("#*#"),           // Emacs auto-save files
+			filesystem.Glob(".#*"),           // Emacs lock files
+			filesystem.Glob("*___jb_tmp___"), // JetBrains safe-write files
+			filesystem.Glob("*___jb_old___"), // JetBrains safe-write files
+			filesystem.Glob("*.kate-swp"),    // Kate swap files
+		),
+		Destination: target,
+	}
+}
+
+func newFollowSymlinksFlag(target *bool) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "follow-symlinks",
+		Usage:       "watch the targets of symlinked folders",
+		EnvVars:     []string{"GOCRANE_FOLLOW_SYMLINKS"},
+		Destination: target,
+	}
+}
+
+func newLocalModulesFlag(target *bool) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "local-modules",
+		Usage:       "watch local modules that are referenced by go.work or replace directives",
+		EnvVars:     []string{"GOCRANE_LOCAL_MODULES"},
+		Value:       true,
+		Destination: target,
+	}
+}
+
 func newMainFlag(target *string) cli.Flag {
 	return &cli.StringFlag{
 		Name:        "main",
@@ -129,6 +191,16 @@ func newBinaryFlag(target *string, required bool) cli.Flag {
 	}
 }
 
+func newDirCacheFlag(target *bool) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "dir-cache",
+		Usage:       "keep a cache of folder listings next to the binary, which avoids listing unchanged folders on startup",
+		EnvVars:     []string{"GOCRANE_DIR_CACHE"},
+		Value:       true,
+		Destination: target,
+	}
+}
+
 func newBuildArgs(target *flag.ShlexStringSlice) cli.Flag {
 	return &cli.GenericFlag{
 		Name:    "build-args",
@@ -149,6 +221,176 @@ func newRunArgs(target *flag.ShlexStringSlice) cli.Flag {
 	}
 }
 
+func newEnvFlag(target *cli.StringSlice) cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "env",
+		Usage:       "environment variable(s) in KEY=VALUE form to pass to the executable",
+		Aliases:     []string{"e"},
+		EnvVars:     []string{"GOCRANE_ENV"},
+		Destination: target,
+	}
+}
+
+func newEnvFileFlag(target *cli.StringSlice) cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "env-file",
+		Usage:       "dotenv file(s) with environment variables to pass to the executable; reloaded on change",
+		Aliases:     []string{"ef"},
+		EnvVars:     []string{"GOCRANE_ENV_FILES"},
+		Destination: target,
+	}
+}
+
+func newBuildEnvFlag(target *cli.StringSlice) cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "build-env",
+		Usage:       "environment variable(s) in KEY=VALUE form to use when building the executable",
+		Aliases:     []string{"be"},
+		EnvVars:     []string{"GOCRANE_BUILD_ENV"},
+		Destination: target,
+	}
+}
+
+func newBuildEnvFileFlag(target *cli.StringSlice) cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:        "build-env-file",
+		Usage:       "dotenv file(s) with environment variables to use when building the executable; rebuilds on change",
+		Aliases:     []string{"bef"},
+		EnvVars:     []string{"GOCRANE_BUILD_ENV_FILES"},
+		Destination: target,
+	}
+}
+
+func newRunOutputFlag(target *string) cli.Flag {
+	return &cli.StringFlag{
+		Name:        "run-output",
+		Usage:       "how to handle the output of the executable (log, passthrough, prefixed)",
+		Aliases:     []string{"ro"},
+		EnvVars:     []string{"GOCRANE_RUN_OUTPUT"},
+		Value:       runOutputLog,
+		Destination: target,
+	}
+}
+
+func newPTYFlag(target *bool) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "pty",
+		Usage:       "run the executable in a pseudo-terminal when attached to a terminal",
+		EnvVars:     []string{"GOCRANE_PTY"},
+		Value:       false,
+		Destination: target,
+	}
+}
+
+func newStdinFlag(target *bool) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "stdin",
+		Usage:       "forward standard input to the executable (type rs to restart or rb to rebuild)",
+		EnvVars:     []string{"GOCRANE_STDIN"},
+		Value:       false,
+		Destination: target,
+	}
+}
+
+func newInteractiveFlag(target *bool) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "interactive",
+		Usage:       "enable keyboard controls when attached to a terminal",
+		Aliases:     []string{"i"},
+		EnvVars:     []string{"GOCRANE_INTERACTIVE"},
+		Value:       false,
+		Destination: target,
+	}
+}
+
+func newAnalysisFlag(target *string) cli.Flag {
+	return &cli.StringFlag{
+		Name:        "analysis",
+		Usage:       "analyze changed packages before restarting: off, warn (restart anyway) or block (keep the previous process)",
+		EnvVars:     []string{"GOCRANE_ANALYSIS"},
+		Value:       analysisOff,
+		Destination: target,
+	}
+}
+
+func newAnalysisCmdFlag(target *flag.ShlexStringSlice) cli.Flag {
+	return &cli.GenericFlag{
+		Name:    "analysis-cmd",
+		Usage:   "command to use for analysis, to which the package paths are appended (default: go vet)",
+		EnvVars: []string{"GOCRANE_ANALYSIS_CMD"},
+		Value:   target,
+	}
+}
+
+func newDiscoverFlag(target *string) cli.Flag {
+	return &cli.StringFlag{
+		Name:        "discover",
+		Usage:       "discover files in watched folders that the application reads on startup: off, propose (report them) or auto (restart when they change)",
+		EnvVars:     []string{"GOCRANE_DISCOVER"},
+		Value:       discoveryOff,
+		Destination: target,
+	}
+}
+
+func newDiscoverDurationFlag(target *time.Duration) cli.Flag {
+	return &cli.DurationFlag{
+		Name:        "discover-duration",
+		Usage:       "amount of time after the application starts during which files that it reads are discovered",
+		Value:       5 * time.Second,
+		EnvVars:     []string{"GOCRANE_DISCOVER_DURATION"},
+		Destination: target,
+	}
+}
+
+func newControlFlag(target *bool) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "control",
+		Usage:       "serve an HTTP API for querying status and triggering actions",
+		EnvVars:     []string{"GOCRANE_CONTROL"},
+		Value:       false,
+		Destination: target,
+	}
+}
+
+func newControlAddrFlag(target *string) cli.Flag {
+	return &cli.StringFlag{
+		Name:        "control-addr",
+		Usage:       "address of the control API (host:port or unix:/path/to/socket)",
+		EnvVars:     []string{"GOCRANE_CONTROL_ADDR"},
+		Value:       control.DefaultAddress(),
+		Destination: target,
+	}
+}
+
+func newControlTimeoutFlag(target *time.Duration) cli.Flag {
+	return &cli.DurationFlag{
+		Name:        "timeout",
+		Usage:       "amount of time to wait for the running instance to respond",
+		Value:       5 * time.Second,
+		EnvVars:     []string{"GOCRANE_CONTROL_TIMEOUT"},
+		Destination: target,
+	}
+}
+
+func newMetricsAddrFlag(target *string) cli.Flag {
+	return &cli.StringFlag{
+		Name:        "metrics-addr",
+		Usage:       "address on which to serve Prometheus metrics (e.g. :9100); disabled when empty",
+		EnvVars:     []string{"GOCRANE_METRICS_ADDR"},
+		Value:       "",
+		Destination: target,
+	}
+}
+
+func newJSONFlag(target *bool) cli.Flag {
+	return &cli.BoolFlag{
+		Name:        "json",
+		Usage:       "print output in JSON format",
+		Value:       false,
+		Destination: target,
+	}
+}
+
 func newBatchDurationFlag(target *time.Duration) cli.Flag {
 	return &cli.DurationFlag{
 		Name:        "batch-duration",
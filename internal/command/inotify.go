@@ -0,0 +1,41 @@
+package command
+
+import (
+	"log/slog"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+// checkWatchLimit warns when the directories that need to be watched exceed
+// the inotify watches that are still available. The watch stage polls the
+// directories that it cannot watch, but polling is slower and more costly,
+// so the user should raise the limit instead.
+func checkWatchLimit(summary *project.Summary) {
+	limit, err := filesystem.InotifyWatchLimit()
+	if err != nil {
+		slog.Debug("Failed to determine inotify watch limit.", "error", err)
+		return
+	}
+	if limit == 0 {
+		return
+	}
+	usage, err := filesystem.InotifyWatchUsage()
+	if err != nil {
+		slog.Debug("Failed to determine inotify watch usage.", "error", err)
+		return
+	}
+	needed := len(summary.WatchedDirs)
+	slog.Debug("Checked inotify watch limit.", "needed", needed, "used", usage, "limit", limit)
+	if usage+needed <= limit {
+		return
+	}
+	slog.Warn("Not enough inotify watches, some directories will be polled for changes instead.",
+		"needed", needed,
+		"used", usage,
+		"limit", limit,
+	)
+	slog.Warn("Raise the limit on the host (not inside a container), for example with " +
+		"'sudo sysctl fs.inotify.max_user_watches=524288', and add the setting to " +
+		"/etc/sysctl.conf to keep it after a reboot.")
+}
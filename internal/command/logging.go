@@ -0,0 +1,29 @@
+package command
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/mokiat/gocrane/internal/logutil"
+)
+
+// setupLogging configures the default logger according to the logging
+// flags. The verbose flag is a shorthand for the debug log level.
+//
+// The returned LevelVar can be used to change the log level at runtime.
+func setupLogging(verbose bool, level, format string) (*slog.LevelVar, error) {
+	logLevel := new(slog.LevelVar)
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	if verbose {
+		logLevel.Set(slog.LevelDebug)
+	}
+	logFormat, err := logutil.ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slog.New(logutil.NewHandler(os.Stderr, logFormat, logLevel)))
+	return logLevel, nil
+}
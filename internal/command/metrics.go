@@ -0,0 +1,14 @@
+package command
+
+import "github.com/mokiat/gocrane/internal/metrics"
+
+var (
+	digestCacheHitsMetric = metrics.Default.NewCounter(
+		"gocrane_digest_cache_hits_total",
+		"Number of startups that reused an existing binary, because its digest matched.",
+	)
+	digestCacheMissesMetric = metrics.Default.NewCounter(
+		"gocrane_digest_cache_misses_total",
+		"Number of startups that had to build from scratch, because the digest did not match.",
+	)
+)
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"log/slog"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+// watchLocalModules adds the local modules that the main module depends on
+// (through go.work or replace directives) to the watch filter. The Go files
+// of these modules are added as default source rules, so that they trigger
+// builds and are included in the digest even when the source rules are
+// scoped to the main module, while explicit source rules still take
+// precedence over them.
+func watchLocalModules(mainDir string, watchFilter, sourceFilter *filesystem.FilterTree) {
+	dirs, err := project.LocalModuleDirs(mainDir)
+	if err != nil {
+		slog.Warn("Failed to determine local modules.", "error", err)
+		return
+	}
+	for _, dir := range dirs {
+		slog.Info("Watching local module.", "path", dir)
+		watchFilter.AcceptPath(dir)
+		for _, rule := range localModuleSourceRules(dir) {
+			sourceFilter.AddDefaultRule(rule)
+		}
+	}
+	warnUnmountedModules(mainDir, dirs)
+}
+
+// localModuleSourceRules returns the rules that select the sources of the
+// local module at the specified directory. Test files are excluded, same as
+// with the default source rules.
+func localModuleSourceRules(dir string) []filesystem.FilterRule {
+	return []filesystem.FilterRule{
+		{Accept: true, Path: dir, Pattern: "*.go"},
+		{Accept: true, Path: dir, Pattern: "go.mod"},
+		{Accept: true, Path: dir, Pattern: "go.sum"},
+		{Accept: false, Path: dir, Pattern: "*_test.go"},
+	}
+}
+
+// warnUnmountedModules warns about local modules that are not part of any
+// bind mount, when the main module is. This usually means that a
+// docker-compose volume is missing and that changes on the host will not
+// be seen.
+func warnUnmountedModules(mainDir string, dirs []string) {
+	if len(dirs) == 0 {
+		return
+	}
+	mountPoints, err := filesystem.MountPoints()
+	if err != nil {
+		slog.Debug("Failed to determine mount points.", "error", err)
+		return
+	}
+	absMainDir, err := filesystem.ToAbsolutePath(mainDir)
+	if err != nil {
+		return
+	}
+	rootMount := filesystem.MountPointOf("/", mountPoints)
+	if mainMount := filesystem.MountPointOf(absMainDir, mountPoints); mainMount == rootMount {
+		return
+	}
+	for _, dir := range dirs {
+		if filesystem.MountPointOf(dir, mountPoints) == rootMount {
+			slog.Warn("Local module is outside of any mounted path, changes to it may not be visible.", "path", dir)
+		}
+	}
+}
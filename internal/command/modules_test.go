@@ -0,0 +1,70 @@
+package command_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/command"
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+var _ = Describe("WatchLocalModules", func() {
+	var (
+		mainDir string
+		libDir  string
+	)
+
+	writeFile := func(path, content string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		root, err := filepath.EvalSymlinks(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		mainDir = filepath.Join(root, "app")
+		libDir = filepath.Join(root, "lib")
+		writeFile(filepath.Join(mainDir, "go.mod"), "module example.com/app\n\ngo 1.22\n\nrequire example.com/lib v0.0.0\n\nreplace example.com/lib => ../lib\n")
+		writeFile(filepath.Join(mainDir, "main.go"), "package main\n")
+		writeFile(filepath.Join(libDir, "go.mod"), "module example.com/lib\n\ngo 1.22\n")
+		writeFile(filepath.Join(libDir, "lib.go"), "package lib\n")
+	})
+
+	It("treats the sources of local modules as sources when source rules are scoped to the main module", func() {
+		watchFilter := filesystem.NewFilterTree()
+		watchFilter.AcceptPath(mainDir)
+		sourceFilter, err := command.BuildFilterTree(
+			[]string{filepath.Join(mainDir, "*", "*.go")},
+			nil,
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		command.WatchLocalModules(mainDir, watchFilter, sourceFilter)
+
+		Expect(watchFilter.IsAccepted(filepath.Join(libDir, "lib.go"))).To(BeTrue())
+		Expect(sourceFilter.IsAccepted(filepath.Join(mainDir, "main.go"))).To(BeTrue())
+		Expect(sourceFilter.IsAccepted(filepath.Join(libDir, "lib.go"))).To(BeTrue())
+		Expect(sourceFilter.IsAccepted(filepath.Join(libDir, "internal", "util.go"))).To(BeTrue())
+		Expect(sourceFilter.IsAccepted(filepath.Join(libDir, "go.mod"))).To(BeTrue())
+		Expect(sourceFilter.IsAccepted(filepath.Join(libDir, "lib_test.go"))).To(BeFalse())
+		Expect(sourceFilter.IsAccepted(filepath.Join(libDir, "README.md"))).To(BeFalse())
+	})
+
+	It("lets explicit source rules take precedence", func() {
+		watchFilter := filesystem.NewFilterTree()
+		watchFilter.AcceptPath(mainDir)
+		sourceFilter, err := command.BuildFilterTree(
+			[]string{filepath.Join(mainDir, "*", "*.go")},
+			[]string{filepath.Join(libDir, "*", "*_gen.go")},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		command.WatchLocalModules(mainDir, watchFilter, sourceFilter)
+
+		Expect(sourceFilter.IsAccepted(filepath.Join(libDir, "lib.go"))).To(BeTrue())
+		Expect(sourceFilter.IsAccepted(filepath.Join(libDir, "lib_gen.go"))).To(BeFalse())
+	})
+})
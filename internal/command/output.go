@@ -0,0 +1,32 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/mokiat/gocrane/internal/logutil"
+)
+
+const (
+	runOutputLog         = "log"
+	runOutputPassthrough = "passthrough"
+	runOutputPrefixed    = "prefixed"
+)
+
+// programOutput returns the writers that should receive the standard output
+// and standard error of the program, based on the specified output mode.
+func programOutput(mode string) (stdout, stderr io.Writer, err error) {
+	switch mode {
+	case runOutputLog:
+		logger := slog.Default().With(logutil.ComponentKey, "program")
+		return logutil.ToWriter(logger.With("stream", "stdout")), logutil.ToWriter(logger.With("stream", "stderr")), nil
+	case runOutputPassthrough:
+		return os.Stdout, os.Stderr, nil
+	case runOutputPrefixed:
+		return logutil.NewPrefixWriter(os.Stdout, "[program]: "), logutil.NewPrefixWriter(os.Stderr, "[program]: "), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown run output mode %q", mode)
+	}
+}
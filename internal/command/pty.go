@@ -0,0 +1,21 @@
+package command
+
+import "log/slog"
+
+// usePseudoTerminal returns whether the program should be run in a
+// pseudo-terminal. When one was requested but cannot be used, the reason is
+// logged and the program writes to plain pipes instead.
+func usePseudoTerminal(requested bool, goos string, isTerminal bool) bool {
+	if !requested {
+		return false
+	}
+	if goos != "linux" {
+		slog.Warn("Pseudo-terminals are only supported on Linux, will not use a pseudo-terminal.", "os", goos)
+		return false
+	}
+	if !isTerminal {
+		slog.Info("Not attached to a terminal, will not use a pseudo-terminal.")
+		return false
+	}
+	return true
+}
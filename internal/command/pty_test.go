@@ -0,0 +1,27 @@
+package command_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/command"
+)
+
+var _ = Describe("UsePseudoTerminal", func() {
+	It("uses a pseudo-terminal on Linux when attached to a terminal", func() {
+		Expect(command.UsePseudoTerminal(true, "linux", true)).To(BeTrue())
+	})
+
+	It("falls back to pipes on systems other than Linux", func() {
+		Expect(command.UsePseudoTerminal(true, "darwin", true)).To(BeFalse())
+		Expect(command.UsePseudoTerminal(true, "windows", true)).To(BeFalse())
+	})
+
+	It("falls back to pipes when not attached to a terminal", func() {
+		Expect(command.UsePseudoTerminal(true, "linux", false)).To(BeFalse())
+	})
+
+	It("does not use a pseudo-terminal unless requested", func() {
+		Expect(command.UsePseudoTerminal(false, "linux", true)).To(BeFalse())
+	})
+})
@@ -3,15 +3,22 @@ package command
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime"
 	"time"
 
 	"github.com/urfave/cli/v2"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/mokiat/gocrane/internal/command/flag"
+	"github.com/mokiat/gocrane/internal/control"
+	"github.com/mokiat/gocrane/internal/filesystem"
+	"github.com/mokiat/gocrane/internal/metrics"
 	"github.com/mokiat/gocrane/internal/pipeline"
 	"github.com/mokiat/gocrane/internal/project"
+	"github.com/mokiat/gocrane/internal/terminal"
 )
 
 func Run() *cli.Command {
@@ -20,16 +27,37 @@ func Run() *cli.Command {
 		Name: "run",
 		Flags: []cli.Flag{
 			newVerboseFlag(&cfg.Verbose),
+			newLogLevelFlag(&cfg.LogLevel),
+			newLogFormatFlag(&cfg.LogFormat),
 			newDirFlag(&cfg.Dirs),
 			newDirExcludeFlag(&cfg.ExcludeDirs),
 			newSourceFlag(&cfg.Sources),
 			newSourceExcludeFlag(&cfg.ExcludeSources),
 			newResourceFlag(&cfg.Resources),
 			newResourceExcludeFlag(&cfg.ExcludeResources),
+			newIgnoreFlag(&cfg.Ignores),
 			newMainFlag(&cfg.MainDir),
+			newLocalModulesFlag(&cfg.LocalModules),
+			newFollowSymlinksFlag(&cfg.FollowSymlinks),
 			newBinaryFlag(&cfg.BinaryFile, false),
+			newDirCacheFlag(&cfg.DirCache),
 			newBuildArgs(&cfg.BuildArgs),
 			newRunArgs(&cfg.RunArgs),
+			newEnvFlag(&cfg.Env),
+			newEnvFileFlag(&cfg.EnvFiles),
+			newBuildEnvFlag(&cfg.BuildEnv),
+			newBuildEnvFileFlag(&cfg.BuildEnvFiles),
+			newRunOutputFlag(&cfg.RunOutput),
+			newPTYFlag(&cfg.PTY),
+			newStdinFlag(&cfg.Stdin),
+			newInteractiveFlag(&cfg.Interactive),
+			newControlFlag(&cfg.Control),
+			newControlAddrFlag(&cfg.ControlAddr),
+			newMetricsAddrFlag(&cfg.MetricsAddr),
+			newAnalysisFlag(&cfg.Analysis),
+			newAnalysisCmdFlag(&cfg.AnalysisCmd),
+			newDiscoverFlag(&cfg.Discover),
+			newDiscoverDurationFlag(&cfg.DiscoverDuration),
 			newBatchDurationFlag(&cfg.BatchDuration),
 			newShutdownTimeoutFlag(&cfg.ShutdownTimeout),
 		},
@@ -41,22 +69,97 @@ func Run() *cli.Command {
 
 type runConfig struct {
 	Verbose          bool
+	LogLevel         string
+	LogFormat        string
 	Dirs             cli.StringSlice
 	ExcludeDirs      cli.StringSlice
 	Sources          cli.StringSlice
 	ExcludeSources   cli.StringSlice
 	Resources        cli.StringSlice
 	ExcludeResources cli.StringSlice
+	Ignores          cli.StringSlice
 	MainDir          string
+	LocalModules     bool
+	FollowSymlinks   bool
 	BinaryFile       string
+	DirCache         bool
 	BuildArgs        flag.ShlexStringSlice
 	RunArgs          flag.ShlexStringSlice
+	Env              cli.StringSlice
+	EnvFiles         cli.StringSlice
+	BuildEnv         cli.StringSlice
+	BuildEnvFiles    cli.StringSlice
+	RunOutput        string
+	PTY              bool
+	Stdin            bool
+	Interactive      bool
+	Control          bool
+	ControlAddr      string
+	MetricsAddr      string
+	Analysis         string
+	Discover         string
+	DiscoverDuration time.Duration
+	AnalysisCmd      flag.ShlexStringSlice
 	BatchDuration    time.Duration
 	ShutdownTimeout  time.Duration
 }
 
 func run(ctx context.Context, cfg runConfig) error {
-	log.Println("Preparing filtering...")
+	logLevel, err := setupLogging(cfg.Verbose, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+	if cfg.Stdin && cfg.Interactive {
+		return fmt.Errorf("the stdin and interactive flags cannot be used together")
+	}
+	verbose := slog.Default().Enabled(ctx, slog.LevelDebug)
+
+	stdout, stderr, err := programOutput(cfg.RunOutput)
+	if err != nil {
+		return err
+	}
+	analysisMode, err := parseAnalysisMode(cfg.Analysis)
+	if err != nil {
+		return err
+	}
+	discoveryMode, err := parseDiscoveryMode(cfg.Discover)
+	if err != nil {
+		return err
+	}
+	if discoveryMode != "" && runtime.GOOS != "linux" {
+		slog.Warn("Discovering resources is only supported on Linux, discovery is disabled.", "os", runtime.GOOS)
+		discoveryMode = ""
+	}
+	if err := validateEnv(cfg.Env.Value()); err != nil {
+		return err
+	}
+	if err := validateEnv(cfg.BuildEnv.Value()); err != nil {
+		return err
+	}
+	usePTY := usePseudoTerminal(cfg.PTY, runtime.GOOS, terminal.IsTerminal(os.Stdout))
+	interactive := cfg.Interactive && terminal.IsTerminal(os.Stdin)
+	if cfg.Interactive && !interactive {
+		slog.Info("Not attached to a terminal, keyboard controls are disabled.")
+	}
+	// The standard input is only ever read through a single relay, which
+	// is used either for input commands or for keyboard controls.
+	var inputRelay *project.InputRelay
+	if cfg.Stdin || interactive {
+		inputRelay = project.NewInputRelay(os.Stdin)
+	}
+	var programInput *project.InputRelay
+	if cfg.Stdin {
+		programInput = inputRelay
+	}
+	if interactive {
+		restoreTerminal, err := terminal.EnableKeyInput(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to enable keyboard controls: %w", err)
+		}
+		defer restoreTerminal()
+	}
+
+	slog.Info("Preparing filtering...")
 	watchFilter, err := buildFilterTree(cfg.Dirs.Value(), cfg.ExcludeDirs.Value())
 	if err != nil {
 		return fmt.Errorf("problem with dir rules: %w", err)
@@ -69,67 +172,107 @@ func run(ctx context.Context, cfg runConfig) error {
 	if err != nil {
 		return fmt.Errorf("problem with resource rules: %w", err)
 	}
+	ignoreFilter, err := buildFilterTree(cfg.Ignores.Value(), nil)
+	if err != nil {
+		return fmt.Errorf("problem with ignore rules: %w", err)
+	}
+	if err := watchEnvFiles(cfg.EnvFiles.Value(), watchFilter, resourceFilter); err != nil {
+		return err
+	}
+	if err := watchEnvFiles(cfg.BuildEnvFiles.Value(), watchFilter, sourceFilter); err != nil {
+		return err
+	}
+	if cfg.LocalModules {
+		watchLocalModules(cfg.MainDir, watchFilter, sourceFilter)
+	}
 	rootDirs := watchFilter.RootPaths()
+	buildEnv := project.NewEnvironment(cfg.BuildEnv.Value(), cfg.BuildEnvFiles.Value())
 
-	var summary *project.Summary
-	if cfg.Verbose || cfg.BinaryFile != "" {
-		log.Println("Analyzing project...")
-		summary = project.Analyze(rootDirs, watchFilter, sourceFilter, resourceFilter)
+	slog.Info("Analyzing project...")
+	var dirCache *filesystem.DirCache
+	if cfg.DirCache && cfg.BinaryFile != "" {
+		dirCache = loadDirCache(cfg.BinaryFile)
 	}
-	if cfg.Verbose {
-		printSummary(summary)
+	summary := project.Analyze(rootDirs, watchFilter, sourceFilter, resourceFilter, cfg.FollowSymlinks, dirCache)
+	if dirCache != nil {
+		saveDirCache(cfg.BinaryFile, dirCache)
 	}
+	if verbose {
+		printSummary(summary, buildEnv)
+	}
+	checkWatchLimit(summary)
 
 	var (
 		fakeChangeEvent *pipeline.ChangeEvent
 		fakeBuildEvent  *pipeline.BuildEvent
 	)
 	if cfg.BinaryFile != "" {
-		log.Println("Reading stored digest...")
+		slog.Info("Reading stored digest...")
 		digestFile := fmt.Sprintf("%s.dig", cfg.BinaryFile)
 		storedDigest, err := project.OpenDigestFile(digestFile)
 		if err != nil {
 			return fmt.Errorf("failed to read digest: %w", err)
 		}
 
-		log.Println("Calculating current digest...")
-		digest, err := calculateDigest(summary)
+		slog.Info("Calculating current digest...")
+		digest, err := calculateDigest(summary, buildEnv)
 		if err != nil {
 			return fmt.Errorf("failed to calculate digest: %w", err)
 		}
 
-		log.Println("Comparing stored and current digests...")
+		slog.Info("Comparing stored and current digests...")
 		if storedDigest == digest {
-			log.Println("\t Digest match, will use existing binary.")
+			slog.Info("Digest match, will use existing binary.", "digest", digest, "path", cfg.BinaryFile)
+			digestCacheHitsMetric.Inc()
 			fakeBuildEvent = &pipeline.BuildEvent{
-				Path: cfg.BinaryFile,
+				Path:   cfg.BinaryFile,
+				Reused: true,
 			}
 		} else {
-			log.Printf("\t Digest mismatch (%s != %s), will build from scratch.", digest, storedDigest)
+			slog.Info("Digest mismatch, will build from scratch.", "digest", digest, "stored_digest", storedDigest)
+			digestCacheMissesMetric.Inc()
 			fakeChangeEvent = &pipeline.ChangeEvent{
-				Paths: []string{pipeline.ForceBuildPath},
+				Reasons: pipeline.ReasonDigestMismatch,
 			}
 		}
 	} else {
 		fakeChangeEvent = &pipeline.ChangeEvent{
-			Paths: []string{pipeline.ForceBuildPath},
+			Reasons: pipeline.ReasonBootstrap,
 		}
 	}
 
-	log.Println("Running pipeline...")
+	slog.Info("Running pipeline...")
 	changeEventQueue := make(pipeline.Queue[pipeline.ChangeEvent], 1024)
 	batchChangeEventQueue := make(pipeline.Queue[pipeline.ChangeEvent])
 	buildEventQueue := make(pipeline.Queue[pipeline.BuildEvent])
+	runEventQueue := buildEventQueue
+	pauseEventQueue := make(pipeline.Queue[pipeline.PauseEvent])
+	stopEventQueue := make(pipeline.Queue[pipeline.StopEvent])
+	var startEventQueue pipeline.Queue[pipeline.StartEvent]
+	if discoveryMode != "" {
+		startEventQueue = make(pipeline.Queue[pipeline.StartEvent], 1)
+	}
+	discoveredResources := pipeline.NewDiscoveredResources()
+
+	status := pipeline.NewStatus()
+	controller := pipeline.NewController(changeEventQueue, pauseEventQueue, stopEventQueue)
 
-	group, groupCtx := errgroup.WithContext(ctx)
+	pipelineCtx, quit := context.WithCancel(ctx)
+	defer quit()
+	group, groupCtx := errgroup.WithContext(pipelineCtx)
 
 	// Watch for filesystem changes.
 	group.Go(pipeline.Watch(
 		groupCtx,
-		cfg.Verbose,
 		rootDirs,
 		watchFilter,
+		sourceFilter,
+		resourceFilter,
+		ignoreFilter,
+		cfg.FollowSymlinks,
 		changeEventQueue,
+		pauseEventQueue,
+		status,
 		fakeChangeEvent,
 	))
 
@@ -147,27 +290,108 @@ func run(ctx context.Context, cfg runConfig) error {
 	// Build executable on new batch changes.
 	group.Go(pipeline.Build(
 		groupCtx,
-		cfg.MainDir,
-		cfg.BuildArgs.Value(),
+		project.NewBuilder(cfg.MainDir, cfg.BuildArgs.Value(), buildEnv),
 		batchChangeEventQueue,
 		buildEventQueue,
 		sourceFilter,
 		resourceFilter,
+		discoveredResources,
+		status,
 		fakeBuildEvent,
 	))
 
+	// Analyze changed packages before running new executables.
+	if analysisMode != "" {
+		runEventQueue = make(pipeline.Queue[pipeline.BuildEvent])
+		group.Go(pipeline.Analyze(
+			groupCtx,
+			project.NewAnalyzer(cfg.MainDir, cfg.AnalysisCmd.Value(), buildEnv),
+			analysisMode,
+			buildEventQueue,
+			runEventQueue,
+			status,
+		))
+	}
+
 	// Run new executables when built.
 	group.Go(pipeline.Run(
 		groupCtx,
-		cfg.RunArgs.Value(),
-		buildEventQueue,
+		project.NewRunner(
+			cfg.RunArgs.Value(),
+			project.NewEnvironment(cfg.Env.Value(), cfg.EnvFiles.Value()),
+			stdout,
+			stderr,
+			usePTY,
+			programInput,
+		),
+		runEventQueue,
+		stopEventQueue,
+		startEventQueue,
+		status,
 		cfg.ShutdownTimeout,
 	))
 
+	// Discover files that new processes read on startup.
+	if discoveryMode != "" {
+		group.Go(pipeline.Discover(
+			groupCtx,
+			startEventQueue,
+			discoveryMode,
+			cfg.DiscoverDuration,
+			watchFilter,
+			sourceFilter,
+			resourceFilter,
+			ignoreFilter,
+			discoveredResources,
+		))
+	}
+
+	// Forward input to the running executable and handle input commands.
+	if cfg.Stdin {
+		group.Go(pipeline.Input(
+			groupCtx,
+			inputRelay,
+			controller,
+		))
+	}
+
+	// Handle keyboard controls.
+	if interactive {
+		group.Go(pipeline.Keyboard(
+			groupCtx,
+			inputRelay,
+			os.Stdout,
+			controller,
+			status,
+			logLevel,
+			quit,
+		))
+	}
+
+	// Serve the control API.
+	if cfg.Control {
+		group.Go(control.Serve(
+			groupCtx,
+			cfg.ControlAddr,
+			control.NewHandler(controller, status),
+		))
+	}
+
+	// Serve metrics.
+	if cfg.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Default.Handler())
+		group.Go(control.Serve(
+			groupCtx,
+			cfg.MetricsAddr,
+			mux,
+		))
+	}
+
 	if err := group.Wait(); err != nil {
 		return fmt.Errorf("pipeline error: %w", err)
 	}
 
-	log.Println("Pipeline stopped.")
+	slog.Info("Pipeline stopped.")
 	return nil
 }
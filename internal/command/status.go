@@ -0,0 +1,119 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/mokiat/gocrane/internal/control"
+	"github.com/mokiat/gocrane/internal/pipeline"
+)
+
+// errFailedState is returned when the running instance reports that the
+// last build has failed, so that the status command can be used as a
+// health check.
+var errFailedState = errors.New("application is in a failed state")
+
+func Status() *cli.Command {
+	var cfg statusConfig
+	return &cli.Command{
+		Name:  "status",
+		Usage: "print the status of a running gocrane instance",
+		Flags: []cli.Flag{
+			newControlAddrFlag(&cfg.ControlAddr),
+			newControlTimeoutFlag(&cfg.Timeout),
+			newJSONFlag(&cfg.JSON),
+		},
+		Action: func(c *cli.Context) error {
+			return showStatus(c.Context, cfg)
+		},
+	}
+}
+
+type statusConfig struct {
+	ControlAddr string
+	Timeout     time.Duration
+	JSON        bool
+}
+
+func showStatus(ctx context.Context, cfg statusConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	client := control.NewClient(cfg.ControlAddr)
+	response, err := client.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	if cfg.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(response); err != nil {
+			return fmt.Errorf("failed to print status: %w", err)
+		}
+	} else {
+		printStatus(response)
+	}
+
+	if response.State == string(pipeline.StateFailed) {
+		return errFailedState
+	}
+	return nil
+}
+
+func printStatus(response control.StatusResponse) {
+	watching := "active"
+	if response.WatchPaused {
+		watching = "paused"
+	}
+	fmt.Printf("state:      %s\n", response.State)
+	fmt.Printf("watching:   %s\n", watching)
+	if response.PID != 0 {
+		fmt.Printf("pid:        %d\n", response.PID)
+		fmt.Printf("uptime:     %s\n", secondsToDuration(response.UptimeSeconds).Round(time.Second))
+		fmt.Printf("binary:     %s\n", response.Binary)
+		fmt.Printf("sha256:     %s\n", response.BinarySHA256)
+	}
+	if response.LastBuildDurationSeconds != 0 {
+		fmt.Printf("last build: %s\n", secondsToDuration(response.LastBuildDurationSeconds).Round(time.Millisecond))
+	}
+	if response.LastBuildError != "" {
+		fmt.Printf("last error: %s\n", response.LastBuildError)
+	}
+	switch {
+	case len(response.LastBuildDiagnostics) > 0:
+		printDiagnostics(response.LastBuildDiagnostics)
+	case response.LastBuildOutput != "":
+		for _, line := range strings.Split(strings.TrimRight(response.LastBuildOutput, "\n"), "\n") {
+			fmt.Printf("\t %s\n", line)
+		}
+	}
+	if response.LastAnalysisError != "" {
+		fmt.Printf("analysis:   %s\n", response.LastAnalysisError)
+		printDiagnostics(response.LastAnalysisDiagnostics)
+	}
+}
+
+func printDiagnostics(diagnostics []control.DiagnosticResponse) {
+	for _, diagnostic := range diagnostics {
+		fmt.Printf("\t %s: %s\n", diagnosticPosition(diagnostic), strings.ReplaceAll(diagnostic.Message, "\n", "\n\t   "))
+	}
+}
+
+func diagnosticPosition(diagnostic control.DiagnosticResponse) string {
+	if diagnostic.Column == 0 {
+		return fmt.Sprintf("%s:%d", diagnostic.File, diagnostic.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", diagnostic.File, diagnostic.Line, diagnostic.Column)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -3,7 +3,8 @@ package command
 import (
 	"crypto/sha256"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 
 	"github.com/mokiat/gocrane/internal/project"
 
@@ -11,7 +12,7 @@ import (
 	"golang.org/x/exp/slices"
 )
 
-func printSummary(summary *project.Summary) {
+func printSummary(summary *project.Summary, buildEnv *project.Environment) {
 	visited := maps.Keys(summary.Visited)
 	slices.Sort(visited)
 	errored := maps.Keys(summary.Errored)
@@ -25,47 +26,57 @@ func printSummary(summary *project.Summary) {
 	watchedResourceFiles := maps.Keys(summary.WatchedResourceFiles)
 	slices.Sort(watchedResourceFiles)
 
-	log.Printf("Visited %d files or folders", len(visited))
+	slog.Debug("Visited files or folders", "count", len(visited))
 	for _, file := range visited {
-		log.Printf("\t Visited: %s", file)
+		slog.Debug("Visited", "path", file)
 	}
 
-	log.Printf("Failed with %d files or folders", len(errored))
+	slog.Debug("Failed with files or folders", "count", len(errored))
 	for _, file := range errored {
 		err := summary.Errored[file]
-		log.Printf("\t Failure: %s (%s)", file, err)
+		slog.Debug("Failure", "path", file, "error", err)
 	}
 
-	log.Printf("Omitted %d files or folders", len(omitted))
+	slog.Debug("Omitted files or folders", "count", len(omitted))
 	for _, file := range omitted {
-		log.Printf("\t Omitted: %s", file)
+		slog.Debug("Omitted", "path", file)
 	}
 
-	log.Printf("Found %d directories to watch", len(watchedDirs))
+	slog.Debug("Found directories to watch", "count", len(watchedDirs))
 	for _, dir := range watchedDirs {
-		log.Printf("\t Watch dir: %s", dir)
+		slog.Debug("Watch dir", "path", dir)
 	}
 
-	log.Printf("Found %d source files (to use as digest)", len(watchedSourceFiles))
+	slog.Debug("Found source files (to use as digest)", "count", len(watchedSourceFiles))
 	for _, file := range watchedSourceFiles {
-		log.Printf("\t Source file: %s", file)
+		slog.Debug("Source file", "path", file)
 	}
 
-	log.Printf("Found %d resource files", len(watchedResourceFiles))
+	slog.Debug("Found resource files", "count", len(watchedResourceFiles))
 	for _, file := range watchedResourceFiles {
-		log.Printf("\t Resource file: %s", file)
+		slog.Debug("Resource file", "path", file)
+	}
+
+	buildVars := buildEnv.Load()
+	slog.Debug("Found build environment variables (to use as digest)", "count", len(buildVars))
+	for _, variable := range buildVars {
+		// Only the name is logged, since values often hold secrets.
+		name, _, _ := strings.Cut(variable, "=")
+		slog.Debug("Build environment variable", "name", name)
 	}
 }
 
-func calculateDigest(summary *project.Summary) (string, error) {
+func calculateDigest(summary *project.Summary, buildEnv *project.Environment) (string, error) {
 	sourceFiles := maps.Keys(summary.WatchedSourceFiles)
 	slices.Sort(sourceFiles)
 
 	dig := sha256.New()
-	for _, file := range sourceFiles {
-		if err := project.WriteFileDigest(dig, string(file)); err != nil {
-			return "", err
-		}
+	if err := project.WriteFilesDigest(dig, sourceFiles); err != nil {
+		return "", err
+	}
+	// Variables are written in order, since later ones take precedence.
+	for _, variable := range buildEnv.Load() {
+		fmt.Fprint(dig, len(variable), variable)
 	}
 	return fmt.Sprintf("%x", dig.Sum(nil)), nil
 }
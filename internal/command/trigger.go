@@ -0,0 +1,51 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/mokiat/gocrane/internal/control"
+)
+
+func Trigger() *cli.Command {
+	var cfg triggerConfig
+	return &cli.Command{
+		Name:      "trigger",
+		Usage:     "request that a running gocrane instance performs an action",
+		ArgsUsage: "rebuild|restart|stop|pause|resume",
+		Flags: []cli.Flag{
+			newControlAddrFlag(&cfg.ControlAddr),
+			newControlTimeoutFlag(&cfg.Timeout),
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("exactly one action needs to be specified")
+			}
+			action := control.Action(c.Args().First())
+			if !slices.Contains(control.Actions(), action) {
+				return fmt.Errorf("unknown action %q", action)
+			}
+			return trigger(c.Context, cfg, action)
+		},
+	}
+}
+
+type triggerConfig struct {
+	ControlAddr string
+	Timeout     time.Duration
+}
+
+func trigger(ctx context.Context, cfg triggerConfig, action control.Action) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	client := control.NewClient(cfg.ControlAddr)
+	if err := client.Trigger(ctx, action); err != nil {
+		return fmt.Errorf("failed to trigger %s: %w", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// unixPrefix is the prefix of addresses that refer to Unix sockets.
+const unixPrefix = "unix:"
+
+// DefaultAddress returns the address that is used by the control API when
+// one is not specified.
+func DefaultAddress() string {
+	return unixPrefix + filepath.Join(os.TempDir(), "gocrane.sock")
+}
+
+// Listen creates a listener for the specified address. Addresses that start
+// with "unix:" refer to Unix sockets and all other addresses are treated as
+// TCP addresses.
+func Listen(address string) (net.Listener, error) {
+	network, location := parseAddress(address)
+	if network == "unix" {
+		if err := removeStaleSocket(location); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen(network, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %q: %w", address, err)
+	}
+	return listener, nil
+}
+
+// Dial connects to the specified address. See Listen for the address
+// format.
+func Dial(ctx context.Context, address string) (net.Conn, error) {
+	network, location := parseAddress(address)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %q: %w", address, err)
+	}
+	return conn, nil
+}
+
+func parseAddress(address string) (network, location string) {
+	if path, ok := strings.CutPrefix(address, unixPrefix); ok {
+		return "unix", path
+	}
+	return "tcp", address
+}
+
+// removeStaleSocket removes a socket file that was left behind by a process
+// that did not shut down cleanly. Sockets that are still in use are not
+// removed.
+func removeStaleSocket(path string) error {
+	stat, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat socket %q: %w", path, err)
+	}
+	if stat.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("file %q is not a socket", path)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %q is already in use", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %q: %w", path, err)
+	}
+	return nil
+}
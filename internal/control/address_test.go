@@ -0,0 +1,58 @@
+package control_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/control"
+)
+
+var _ = Describe("Listen", func() {
+	var socketPath string
+
+	BeforeEach(func() {
+		socketPath = filepath.Join(GinkgoT().TempDir(), "test.sock")
+	})
+
+	It("listens on a unix socket", func() {
+		listener, err := control.Listen("unix:" + socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer listener.Close()
+		Expect(listener.Addr().Network()).To(Equal("unix"))
+
+		conn, err := control.Dial(context.Background(), "unix:"+socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		conn.Close()
+	})
+
+	It("replaces a stale socket", func() {
+		listener, err := net.Listen("unix", socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+		listener.Close()
+
+		listener, err = control.Listen("unix:" + socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		listener.Close()
+	})
+
+	It("does not replace a socket that is in use", func() {
+		listener, err := control.Listen("unix:" + socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer listener.Close()
+
+		_, err = control.Listen("unix:" + socketPath)
+		Expect(err).To(MatchError(ContainSubstring("already in use")))
+	})
+
+	It("does not replace regular files", func() {
+		Expect(os.WriteFile(socketPath, nil, 0o644)).To(Succeed())
+		_, err := control.Listen("unix:" + socketPath)
+		Expect(err).To(MatchError(ContainSubstring("not a socket")))
+	})
+})
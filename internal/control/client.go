@@ -0,0 +1,87 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Action is a request that can be made to a running instance.
+type Action string
+
+const (
+	ActionRebuild Action = "rebuild"
+	ActionRestart Action = "restart"
+	ActionStop    Action = "stop"
+	ActionPause   Action = "pause"
+	ActionResume  Action = "resume"
+)
+
+// Actions returns all supported actions.
+func Actions() []Action {
+	return []Action{ActionRebuild, ActionRestart, ActionStop, ActionPause, ActionResume}
+}
+
+// NewClient creates a new Client that connects to the control API at the
+// specified address.
+func NewClient(address string) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return Dial(ctx, address)
+				},
+			},
+		},
+	}
+}
+
+// Client can be used to query and control a running instance.
+type Client struct {
+	httpClient *http.Client
+}
+
+// Status returns the status of the running instance.
+func (c *Client) Status(ctx context.Context) (StatusResponse, error) {
+	var response StatusResponse
+	if err := c.do(ctx, http.MethodGet, "/status", &response); err != nil {
+		return StatusResponse{}, err
+	}
+	return response, nil
+}
+
+// Trigger requests that the running instance performs the specified action.
+func (c *Client) Trigger(ctx context.Context, action Action) error {
+	return c.do(ctx, http.MethodPost, "/"+string(action), nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, target any) error {
+	// The host is ignored, since connections are established by the
+	// transport, but it needs to be a valid one.
+	request, err := http.NewRequestWithContext(ctx, method, "http://gocrane"+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		var errResponse ErrorResponse
+		if err := json.NewDecoder(response.Body).Decode(&errResponse); err == nil && errResponse.Error != "" {
+			return fmt.Errorf("request failed with status %d: %s", response.StatusCode, errResponse.Error)
+		}
+		return fmt.Errorf("request failed with status %d", response.StatusCode)
+	}
+	if target == nil {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package control_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/control"
+	"github.com/mokiat/gocrane/internal/pipeline"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		ctx       context.Context
+		ctxCancel func()
+		changes   pipeline.Queue[pipeline.ChangeEvent]
+		client    *control.Client
+	)
+
+	BeforeEach(func() {
+		ctx, ctxCancel = context.WithCancel(context.Background())
+		changes = make(pipeline.Queue[pipeline.ChangeEvent], 1)
+		controller := pipeline.NewController(
+			changes,
+			make(pipeline.Queue[pipeline.PauseEvent]),
+			make(pipeline.Queue[pipeline.StopEvent]),
+		)
+		handler := control.NewHandler(controller, pipeline.NewStatus())
+
+		address := "unix:" + filepath.Join(GinkgoT().TempDir(), "test.sock")
+		go control.Serve(ctx, address, handler)()
+		client = control.NewClient(address)
+		Eventually(func() error {
+			_, err := client.Status(ctx)
+			return err
+		}).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		ctxCancel()
+	})
+
+	It("fetches the status", func() {
+		response, err := client.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.State).To(Equal(string(pipeline.StateWatching)))
+	})
+
+	It("triggers actions", func() {
+		Expect(client.Trigger(ctx, control.ActionRebuild)).To(Succeed())
+		var changeEvent pipeline.ChangeEvent
+		Expect(changes).To(Receive(&changeEvent))
+		Expect(changeEvent.Reasons).To(Equal(pipeline.ReasonRebuildRequest))
+	})
+
+	It("reports unknown actions", func() {
+		err := client.Trigger(ctx, control.Action("unknown"))
+		Expect(err).To(MatchError(ContainSubstring("status 404")))
+	})
+	It("gives up when the instance does not respond", func() {
+		address := filepath.Join(GinkgoT().TempDir(), "stuck.sock")
+		listener, err := net.Listen("unix", address)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(listener.Close)
+		go func() {
+			// The request is read but never answered.
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}()
+
+		timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer timeoutCancel()
+		_, err = control.NewClient("unix:" + address).Status(timeoutCtx)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+})
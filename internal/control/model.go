@@ -0,0 +1,31 @@
+package control
+
+// StatusResponse is the response of the status endpoint.
+type StatusResponse struct {
+	State                    string               `json:"state"`
+	WatchPaused              bool                 `json:"watch_paused"`
+	Binary                   string               `json:"binary,omitempty"`
+	BinarySHA256             string               `json:"binary_sha256,omitempty"`
+	PID                      int                  `json:"pid,omitempty"`
+	UptimeSeconds            float64              `json:"uptime_seconds,omitempty"`
+	LastBuildDurationSeconds float64              `json:"last_build_duration_seconds,omitempty"`
+	LastBuildError           string               `json:"last_build_error,omitempty"`
+	LastBuildOutput          string               `json:"last_build_output,omitempty"`
+	LastBuildDiagnostics     []DiagnosticResponse `json:"last_build_diagnostics,omitempty"`
+	LastAnalysisError        string               `json:"last_analysis_error,omitempty"`
+	LastAnalysisDiagnostics  []DiagnosticResponse `json:"last_analysis_diagnostics,omitempty"`
+}
+
+// DiagnosticResponse describes a problem that was reported by the compiler.
+type DiagnosticResponse struct {
+	Package string `json:"package,omitempty"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse is the response of an endpoint that has failed.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
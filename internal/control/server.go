@@ -0,0 +1,148 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mokiat/gocrane/internal/pipeline"
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+// shutdownTimeout is the amount of time to wait for in-flight requests to
+// complete when the server is stopped.
+const shutdownTimeout = time.Second
+
+// NewHandler creates an http.Handler that exposes the specified pipeline
+// status and controller.
+//
+// The following endpoints are available:
+//
+//	GET  /status  - returns the current state of the pipeline
+//	POST /rebuild - rebuilds and restarts the program
+//	POST /restart - restarts the program
+//	POST /stop    - stops the program until the next rebuild or restart
+//	POST /pause   - pauses watching for changes
+//	POST /resume  - resumes watching for changes
+func NewHandler(controller *pipeline.Controller, status *pipeline.Status) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, newStatusResponse(status.Snapshot()))
+	})
+	mux.Handle(actionPattern(ActionRebuild), actionHandler(controller.Rebuild))
+	mux.Handle(actionPattern(ActionRestart), actionHandler(controller.Restart))
+	mux.Handle(actionPattern(ActionStop), actionHandler(controller.Stop))
+	mux.Handle(actionPattern(ActionPause), actionHandler(func(ctx context.Context) bool {
+		return controller.SetWatchPaused(ctx, true)
+	}))
+	mux.Handle(actionPattern(ActionResume), actionHandler(func(ctx context.Context) bool {
+		return controller.SetWatchPaused(ctx, false)
+	}))
+	return mux
+}
+
+// Serve returns a pipeline stage that serves the specified handler on the
+// specified address until the context is done. See Listen for the address
+// format.
+func Serve(ctx context.Context, address string, handler http.Handler) func() error {
+	return func() error {
+		listener, err := Listen(address)
+		if err != nil {
+			return err
+		}
+
+		server := &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+
+		stopped := make(chan struct{})
+		defer close(stopped)
+		go func() {
+			select {
+			case <-ctx.Done():
+				shutdownCtx, shutdownFunc := context.WithTimeout(context.Background(), shutdownTimeout)
+				defer shutdownFunc()
+				server.Shutdown(shutdownCtx)
+			case <-stopped:
+			}
+		}()
+
+		slog.Info("Serving HTTP requests.", "address", address)
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+func actionPattern(action Action) string {
+	return "POST /" + string(action)
+}
+
+func actionHandler(action func(ctx context.Context) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !action(r.Context()) {
+			writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{
+				Error: "pipeline did not accept the request",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+func newStatusResponse(snapshot pipeline.Snapshot) StatusResponse {
+	response := StatusResponse{
+		State:                    string(snapshot.State),
+		WatchPaused:              snapshot.WatchPaused,
+		LastBuildDurationSeconds: snapshot.LastBuildDuration.Seconds(),
+	}
+	if process := snapshot.Process; process != nil {
+		response.Binary = process.Binary
+		response.BinarySHA256 = process.BinarySHA256
+		response.PID = process.PID
+		response.UptimeSeconds = time.Since(process.StartTime).Seconds()
+	}
+	if err := snapshot.LastBuildError; err != nil {
+		response.LastBuildError = err.Error()
+		var buildErr *project.BuildError
+		if errors.As(err, &buildErr) {
+			response.LastBuildOutput = buildErr.Output
+			response.LastBuildDiagnostics = newDiagnosticResponses(buildErr.Diagnostics)
+		}
+	}
+	if err := snapshot.LastAnalysisError; err != nil {
+		response.LastAnalysisError = err.Error()
+		var analysisErr *project.AnalysisError
+		if errors.As(err, &analysisErr) {
+			response.LastAnalysisDiagnostics = newDiagnosticResponses(analysisErr.Diagnostics)
+		}
+	}
+	return response
+}
+
+func newDiagnosticResponses(diagnostics []project.Diagnostic) []DiagnosticResponse {
+	var result []DiagnosticResponse
+	for _, diagnostic := range diagnostics {
+		result = append(result, DiagnosticResponse{
+			Package: diagnostic.Package,
+			File:    diagnostic.File,
+			Line:    diagnostic.Line,
+			Column:  diagnostic.Column,
+			Message: diagnostic.Message,
+		})
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Debug("Failed to write response.", "stage", "control", "error", err)
+	}
+}
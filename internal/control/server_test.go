@@ -0,0 +1,83 @@
+package control_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/control"
+	"github.com/mokiat/gocrane/internal/pipeline"
+)
+
+var _ = Describe("Handler", func() {
+	var (
+		changes pipeline.Queue[pipeline.ChangeEvent]
+		pauses  pipeline.Queue[pipeline.PauseEvent]
+		stops   pipeline.Queue[pipeline.StopEvent]
+		handler http.Handler
+	)
+
+	BeforeEach(func() {
+		changes = make(pipeline.Queue[pipeline.ChangeEvent], 1)
+		pauses = make(pipeline.Queue[pipeline.PauseEvent], 1)
+		stops = make(pipeline.Queue[pipeline.StopEvent], 1)
+		controller := pipeline.NewController(changes, pauses, stops)
+		handler = control.NewHandler(controller, pipeline.NewStatus())
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder
+	}
+
+	It("reports the status", func() {
+		recorder := serve(http.MethodGet, "/status")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var response control.StatusResponse
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+		Expect(response).To(Equal(control.StatusResponse{
+			State: "watching",
+		}))
+	})
+
+	It("requests a rebuild", func() {
+		Expect(serve(http.MethodPost, "/rebuild").Code).To(Equal(http.StatusAccepted))
+		var changeEvent pipeline.ChangeEvent
+		Expect(changes).To(Receive(&changeEvent))
+		Expect(changeEvent.Reasons).To(Equal(pipeline.ReasonRebuildRequest))
+	})
+
+	It("requests a restart", func() {
+		Expect(serve(http.MethodPost, "/restart").Code).To(Equal(http.StatusAccepted))
+		var changeEvent pipeline.ChangeEvent
+		Expect(changes).To(Receive(&changeEvent))
+		Expect(changeEvent.Reasons).To(Equal(pipeline.ReasonRestartRequest))
+	})
+
+	It("requests that the program be stopped", func() {
+		Expect(serve(http.MethodPost, "/stop").Code).To(Equal(http.StatusAccepted))
+		Expect(stops).To(Receive())
+	})
+
+	It("requests that watching be paused and resumed", func() {
+		var pauseEvent pipeline.PauseEvent
+		Expect(serve(http.MethodPost, "/pause").Code).To(Equal(http.StatusAccepted))
+		Expect(pauses).To(Receive(&pauseEvent))
+		Expect(pauseEvent.Paused).To(BeTrue())
+
+		Expect(serve(http.MethodPost, "/resume").Code).To(Equal(http.StatusAccepted))
+		Expect(pauses).To(Receive(&pauseEvent))
+		Expect(pauseEvent.Paused).To(BeFalse())
+	})
+
+	It("rejects actions with the wrong method", func() {
+		Expect(serve(http.MethodGet, "/rebuild").Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(changes).ToNot(Receive())
+	})
+})
@@ -0,0 +1,205 @@
+package filesystem
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// dirCacheVersion needs to be incremented whenever the persisted format of
+// the DirCache changes, so that old files are ignored.
+const dirCacheVersion = 1
+
+// dirCacheRacyInterval is the minimum age of a directory modification for
+// its listing to be stored. Some filesystems have a coarse modification time
+// granularity, so a directory that changes again shortly after it was listed
+// could otherwise keep the same modification time and go unnoticed.
+const dirCacheRacyInterval = 2 * time.Second
+
+// NewDirCache creates an empty DirCache.
+func NewDirCache() *DirCache {
+	return &DirCache{
+		dirs: make(map[string]cachedDir),
+		next: make(map[string]cachedDir),
+	}
+}
+
+// LoadDirCache reads a DirCache that was previously saved to the
+// specified file.
+func LoadDirCache(path string) (*DirCache, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %q: %w", path, err)
+	}
+	defer file.Close()
+
+	var state dirCacheState
+	if err := gob.NewDecoder(file).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode file %q: %w", path, err)
+	}
+	if state.Version != dirCacheVersion {
+		return nil, fmt.Errorf("file %q has unsupported version %d", path, state.Version)
+	}
+	cache := NewDirCache()
+	cache.dirs = state.Dirs
+	return cache, nil
+}
+
+// DirCache remembers the listings of directories together with their
+// modification times, so that a subsequent traversal only needs to read
+// the directories that have changed in the meantime.
+//
+// Only the names and types of entries are cached. The information of
+// files is not, since modifying a file does not change the modification
+// time of its directory, so it could not be validated without a stat of
+// the file anyway. Calling Info on a cached entry inspects the file.
+type DirCache struct {
+	mu   sync.Mutex
+	dirs map[string]cachedDir
+	next map[string]cachedDir
+
+	reused int
+	read   int
+}
+
+// Traverse works like the package-level Traverse function but lists
+// directories through the cache.
+func (c *DirCache) Traverse(root string, callback TraverseFunc) {
+	traverse(root, c.readDir, callback)
+}
+
+// TraverseSymlinks works like the package-level TraverseSymlinks function
+// but lists directories through the cache.
+func (c *DirCache) TraverseSymlinks(root string, callback TraverseFunc) {
+	traverseSymlinks(root, c.readDir, callback)
+}
+
+// Stats returns the number of directory listings that were reused from the
+// cache and the number that had to be read from the filesystem.
+func (c *DirCache) Stats() (reused, read int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reused, c.read
+}
+
+// Save writes the listings of the directories that were traversed through
+// this cache to the specified file. Directories that were not traversed
+// are dropped.
+func (c *DirCache) Save(path string) error {
+	// The file is replaced atomically, so that a concurrent load never
+	// observes a partially written cache.
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %q: %w", path, err)
+	}
+	defer os.Remove(file.Name())
+
+	c.mu.Lock()
+	state := dirCacheState{
+		Version: dirCacheVersion,
+		Dirs:    c.next,
+	}
+	err = gob.NewEncoder(file).Encode(state)
+	c.mu.Unlock()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode file %q: %w", file.Name(), err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close file %q: %w", file.Name(), err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace file %q: %w", path, err)
+	}
+	return nil
+}
+
+func (c *DirCache) readDir(path string) ([]fs.DirEntry, error) {
+	now := time.Now()
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		c.mu.Lock()
+		dir, ok := c.dirs[path]
+		if ok && dir.ModTime.Equal(info.ModTime()) {
+			c.next[path] = dir
+			c.reused++
+			c.mu.Unlock()
+			return dir.dirEntries(path), nil
+		}
+		c.mu.Unlock()
+	}
+
+	entries, err := os.ReadDir(path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.read++
+	if err != nil {
+		return nil, err
+	}
+	if statErr == nil && now.Sub(info.ModTime()) >= dirCacheRacyInterval {
+		c.next[path] = newCachedDir(info.ModTime(), entries)
+	}
+	return entries, nil
+}
+
+type dirCacheState struct {
+	Version int
+	Dirs    map[string]cachedDir
+}
+
+func newCachedDir(modTime time.Time, entries []fs.DirEntry) cachedDir {
+	result := cachedDir{
+		ModTime: modTime,
+		Entries: make([]cachedDirEntry, len(entries)),
+	}
+	for i, entry := range entries {
+		result.Entries[i] = cachedDirEntry{
+			EntryName: entry.Name(),
+			EntryType: entry.Type(),
+		}
+	}
+	return result
+}
+
+type cachedDir struct {
+	ModTime time.Time
+	Entries []cachedDirEntry
+}
+
+func (d cachedDir) dirEntries(dirPath string) []fs.DirEntry {
+	result := make([]fs.DirEntry, len(d.Entries))
+	for i, entry := range d.Entries {
+		entry.dirPath = dirPath
+		result[i] = entry
+	}
+	return result
+}
+
+var _ fs.DirEntry = cachedDirEntry{}
+
+type cachedDirEntry struct {
+	EntryName string
+	EntryType fs.FileMode
+
+	dirPath string
+}
+
+func (e cachedDirEntry) Name() string {
+	return e.EntryName
+}
+
+func (e cachedDirEntry) IsDir() bool {
+	return e.EntryType.IsDir()
+}
+
+func (e cachedDirEntry) Type() fs.FileMode {
+	return e.EntryType
+}
+
+func (e cachedDirEntry) Info() (fs.FileInfo, error) {
+	return os.Lstat(filepath.Join(e.dirPath, e.EntryName))
+}
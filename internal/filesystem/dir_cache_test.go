@@ -0,0 +1,122 @@
+package filesystem_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+var _ = Describe("DirCache", func() {
+	var (
+		rootDir   string
+		cacheFile string
+		oldTime   time.Time
+	)
+
+	traverse := func(cache *filesystem.DirCache) []string {
+		var visited []string
+		cache.Traverse(rootDir, func(path string, isDir bool, err error) error {
+			Expect(err).ToNot(HaveOccurred())
+			visited = append(visited, path)
+			return nil
+		})
+		return visited
+	}
+
+	reload := func(cache *filesystem.DirCache) *filesystem.DirCache {
+		Expect(cache.Save(cacheFile)).To(Succeed())
+		cache, err := filesystem.LoadDirCache(cacheFile)
+		Expect(err).ToNot(HaveOccurred())
+		return cache
+	}
+
+	// age sets the modification time of the folders far enough in the past
+	// for their listings to be cached.
+	age := func(dirs ...string) {
+		for _, dir := range dirs {
+			Expect(os.Chtimes(dir, oldTime, oldTime)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		rootDir = filepath.Join(tempDir, "project")
+		cacheFile = filepath.Join(tempDir, "cache")
+		oldTime = time.Now().Add(-time.Hour).Truncate(time.Second)
+		Expect(os.MkdirAll(filepath.Join(rootDir, "cmd"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(rootDir, "cmd", "main.go"), nil, 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(rootDir, "go.mod"), nil, 0o644)).To(Succeed())
+		age(rootDir, filepath.Join(rootDir, "cmd"))
+	})
+
+	It("traverses like Traverse", func() {
+		var expected []string
+		filesystem.Traverse(rootDir, func(path string, isDir bool, err error) error {
+			expected = append(expected, path)
+			return nil
+		})
+		cache := filesystem.NewDirCache()
+		Expect(traverse(cache)).To(Equal(expected))
+		Expect(traverse(reload(cache))).To(Equal(expected))
+	})
+
+	It("reuses the listings of unchanged folders", func() {
+		cache := filesystem.NewDirCache()
+		traverse(cache)
+		cache = reload(cache)
+		traverse(cache)
+		reused, read := cache.Stats()
+		Expect(reused).To(Equal(2))
+		Expect(read).To(Equal(0))
+	})
+
+	It("reads folders that have changed", func() {
+		cache := filesystem.NewDirCache()
+		traverse(cache)
+		cache = reload(cache)
+
+		newFile := filepath.Join(rootDir, "cmd", "util.go")
+		Expect(os.WriteFile(newFile, nil, 0o644)).To(Succeed())
+		Expect(traverse(cache)).To(ContainElement(newFile))
+		reused, read := cache.Stats()
+		Expect(reused).To(Equal(1))
+		Expect(read).To(Equal(1))
+	})
+
+	It("does not cache folders that have changed recently", func() {
+		Expect(os.Chtimes(filepath.Join(rootDir, "cmd"), time.Now(), time.Now())).To(Succeed())
+		cache := filesystem.NewDirCache()
+		traverse(cache)
+		cache = reload(cache)
+		traverse(cache)
+		reused, read := cache.Stats()
+		Expect(reused).To(Equal(1))
+		Expect(read).To(Equal(1))
+	})
+
+	It("drops folders that were not traversed", func() {
+		cache := filesystem.NewDirCache()
+		traverse(cache)
+		cache = reload(cache)
+		cache = reload(cache)
+		traverse(cache)
+		_, read := cache.Stats()
+		Expect(read).To(Equal(2))
+	})
+
+	It("reports a missing cache file", func() {
+		_, err := filesystem.LoadDirCache(filepath.Join(rootDir, "missing"))
+		Expect(err).To(MatchError(os.ErrNotExist))
+	})
+
+	It("reports a corrupt cache file", func() {
+		Expect(os.WriteFile(cacheFile, []byte("garbage"), 0o644)).To(Succeed())
+		_, err := filesystem.LoadDirCache(cacheFile)
+		Expect(err).To(HaveOccurred())
+	})
+})
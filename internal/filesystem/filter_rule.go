@@ -0,0 +1,93 @@
+package filesystem
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const negationPrefix = "!"
+
+// FilterRule represents a single rule of a FilterTree.
+type FilterRule struct {
+
+	// Accept indicates whether matching paths should be accepted or rejected.
+	Accept bool
+
+	// Path is the path that the rule applies to. For glob rules it limits
+	// the glob to sub-paths of Path. It is empty for global glob rules.
+	Path AbsolutePath
+
+	// Pattern is the glob pattern of the rule. It is empty for path rules.
+	Pattern string
+}
+
+// IsGlob returns whether this rule matches paths through a glob pattern.
+func (r FilterRule) IsGlob() bool {
+	return r.Pattern != ""
+}
+
+// String returns the textual form of the rule, as accepted by
+// ParseFilterRule.
+func (r FilterRule) String() string {
+	var result string
+	switch {
+	case !r.IsGlob():
+		result = r.Path
+	case r.Path == "":
+		result = Glob(r.Pattern)
+	default:
+		result = r.Path + string(filepath.Separator) + Glob(r.Pattern)
+	}
+	if !r.Accept {
+		result = negationPrefix + result
+	}
+	return result
+}
+
+// ParseFilterRule parses a single filter rule entry.
+//
+// An entry can be a path, a glob (e.g. `*/*.go`), or a path followed by
+// a glob (e.g. `./internal/*/*_test.go`), in which case the glob only
+// applies to sub-paths of that path. Entries are accepting unless they
+// are prefixed with `!`, in which case they are rejecting.
+func ParseFilterRule(entry string) (FilterRule, error) {
+	rule := FilterRule{
+		Accept: true,
+	}
+	if rest, ok := strings.CutPrefix(entry, negationPrefix); ok {
+		rule.Accept = false
+		entry = rest
+	}
+
+	location, glob := entry, ""
+	if IsGlob(entry) {
+		location, glob = "", entry
+	} else if index := strings.Index(entry, string(filepath.Separator)+globPrefix); index >= 0 {
+		location, glob = entry[:index], entry[index+1:]
+	}
+
+	if glob != "" {
+		pattern := Pattern(glob)
+		if strings.ContainsRune(pattern, filepath.Separator) {
+			return FilterRule{}, fmt.Errorf("glob %q should match a single path segment", glob)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return FilterRule{}, fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+		rule.Pattern = pattern
+	}
+
+	if location != "" {
+		path, err := ToAbsolutePath(location)
+		if err != nil {
+			return FilterRule{}, fmt.Errorf("error converting path %q to absolute: %w", location, err)
+		}
+		rule.Path = path
+	}
+
+	if !rule.IsGlob() && rule.Path == "" {
+		return FilterRule{}, fmt.Errorf("rule should specify a path or a glob")
+	}
+	return rule, nil
+}
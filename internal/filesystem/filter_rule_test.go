@@ -0,0 +1,88 @@
+package filesystem_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+var _ = Describe("FilterRule", func() {
+
+	Describe("ParseFilterRule", func() {
+		It("parses path rules", func() {
+			rule, err := filesystem.ParseFilterRule("/tmp/example")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rule).To(Equal(filesystem.FilterRule{
+				Accept: true,
+				Path:   "/tmp/example",
+			}))
+		})
+
+		It("parses glob rules", func() {
+			rule, err := filesystem.ParseFilterRule("*/*.go")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rule).To(Equal(filesystem.FilterRule{
+				Accept:  true,
+				Pattern: "*.go",
+			}))
+		})
+
+		It("parses scoped glob rules", func() {
+			rule, err := filesystem.ParseFilterRule("/tmp/example/*/*_test.go")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rule).To(Equal(filesystem.FilterRule{
+				Accept:  true,
+				Path:    "/tmp/example",
+				Pattern: "*_test.go",
+			}))
+		})
+
+		It("parses negated rules", func() {
+			rule, err := filesystem.ParseFilterRule("!*/*_test.go")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rule).To(Equal(filesystem.FilterRule{
+				Accept:  false,
+				Pattern: "*_test.go",
+			}))
+		})
+
+		It("converts relative paths to absolute", func() {
+			rule, err := filesystem.ParseFilterRule("!./example")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rule.Accept).To(BeFalse())
+			Expect(rule.Path).To(HaveSuffix("/example"))
+		})
+
+		It("rejects globs spanning multiple segments", func() {
+			_, err := filesystem.ParseFilterRule("*/hello/world")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects malformed globs", func() {
+			_, err := filesystem.ParseFilterRule("*/[a-")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects empty rules", func() {
+			_, err := filesystem.ParseFilterRule("!")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("String", func() {
+		It("produces a value that can be parsed back", func() {
+			for _, entry := range []string{
+				"/tmp/example",
+				"!/tmp/example",
+				"*/*.go",
+				"!/tmp/example/*/*_test.go",
+			} {
+				rule, err := filesystem.ParseFilterRule(entry)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rule.String()).To(Equal(entry))
+			}
+		})
+	})
+
+})
@@ -2,9 +2,11 @@ package filesystem
 
 import (
 	"fmt"
+	"math"
 	"path/filepath"
 
 	"github.com/mokiat/gog/ds"
+	"golang.org/x/exp/slices"
 )
 
 // NewFilterTree creates a new empty FilterTree instance.
@@ -16,15 +18,20 @@ func NewFilterTree() *FilterTree {
 
 // FilterTree is a data structure that can be used to mark specific filesystem
 // paths as accepted and others as rejected. This can also be achieved through
-// global glob patterns.
+// global glob patterns or through glob patterns that are scoped to a path.
+//
+// Rules are evaluated for each segment of a path in the order in which they
+// were added, with the last matching rule taking precedence. A segment that
+// is not matched by any rule inherits the outcome of its parent.
 //
 // The structure then provides a means through which one can test whether
 // a given file path is accepted or rejected by the filter.
 type FilterTree struct {
+	ruleCount        int
+	defaultRuleCount int
 
 	// pattern related filtering
-	acceptPatterns []string
-	rejectPatterns []string
+	globs []orderedFilterRule
 
 	// directory related filtering
 	root *filterTreeNode
@@ -33,136 +40,250 @@ type FilterTree struct {
 // RootPaths returns the top-most paths that are accepted.
 func (t *FilterTree) RootPaths() []AbsolutePath {
 	result := ds.NewList[string](0)
+	rootCursor := filterCursor{
+		node: t.root,
+	}
 	for childName := range t.root.children {
-		childNode, isChildAccepted := t.navigateAway(t.root, false, childName)
-		if isChildAccepted {
+		childCursor, _ := t.navigateAway(rootCursor, childName)
+		if childCursor.accepted {
 			result.Add(childName)
 		}
-		t.findRoots(result, childName, childNode, isChildAccepted)
+		t.findRoots(result, childName, childCursor)
 	}
 	return result.Items()
 }
 
+// AddRule appends the specified rule to the filter. It takes precedence
+// over all rules that were added before it.
+func (t *FilterTree) AddRule(rule FilterRule) {
+	t.addOrderedRule(orderedFilterRule{
+		order: t.ruleCount,
+		rule:  rule,
+	})
+	t.ruleCount++
+}
+
+// AddDefaultRule appends the specified rule to the default rules of the
+// filter. Default rules take precedence over default rules that were added
+// before them, but all rules that are added through AddRule take precedence
+// over them, regardless of when they were added.
+func (t *FilterTree) AddDefaultRule(rule FilterRule) {
+	t.addOrderedRule(orderedFilterRule{
+		order: math.MinInt + t.defaultRuleCount,
+		rule:  rule,
+	})
+	t.defaultRuleCount++
+}
+
+func (t *FilterTree) addOrderedRule(ordered orderedFilterRule) {
+	rule := ordered.rule
+	switch {
+	case !rule.IsGlob():
+		// A node holds a single path rule, which is the one that takes
+		// precedence.
+		node := t.nodeAt(rule.Path)
+		if node.pathRule == nil || ordered.order > node.pathRule.order {
+			node.pathRule = &ordered
+		}
+	case rule.Path == "":
+		t.globs = append(t.globs, ordered)
+	default:
+		node := t.nodeAt(rule.Path)
+		node.scopedGlobs = append(node.scopedGlobs, ordered)
+	}
+}
+
 // AcceptGlob requests that sub-paths of a path segment that matches
 // the specified glob should be accepted.
 func (t *FilterTree) AcceptGlob(glob string) {
-	t.acceptPatterns = append(t.acceptPatterns, Pattern(glob))
+	t.AddRule(FilterRule{
+		Accept:  true,
+		Pattern: Pattern(glob),
+	})
 }
 
 // RejectGlob requests that sub-paths of a path segment that matches
 // the specified glob should not be accepted.
 func (t *FilterTree) RejectGlob(glob string) {
-	t.rejectPatterns = append(t.rejectPatterns, Pattern(glob))
+	t.AddRule(FilterRule{
+		Accept:  false,
+		Pattern: Pattern(glob),
+	})
 }
 
 // AcceptPath requests that the specified path be accepted.
 func (t *FilterTree) AcceptPath(path AbsolutePath) {
-	t.acceptRelativePath(t.root, path)
+	t.AddRule(FilterRule{
+		Accept: true,
+		Path:   path,
+	})
 }
 
 // RejectPath requests that the specified path be rejected.
 func (t *FilterTree) RejectPath(path AbsolutePath) {
-	t.rejectRelativePath(t.root, path)
+	t.AddRule(FilterRule{
+		Accept: false,
+		Path:   path,
+	})
 }
 
 // IsAccepted returns whether the specified path is allowed by this filter.
 func (t *FilterTree) IsAccepted(path AbsolutePath) bool {
-	var (
-		current           = t.root
-		isCurrentAccepted = false
-	)
+	current := filterCursor{
+		node: t.root,
+	}
 	childName, nextChildPath := CutPath(path)
-	current, isCurrentAccepted = t.navigateAway(current, isCurrentAccepted, childName)
+	current, _ = t.navigateAway(current, childName)
 	for nextChildPath != "" {
 		childName, nextChildPath = CutPath(nextChildPath)
-		current, isCurrentAccepted = t.navigateAway(current, isCurrentAccepted, childName)
+		current, _ = t.navigateAway(current, childName)
 	}
-	return isCurrentAccepted
+	return current.accepted
 }
 
-func (t *FilterTree) acceptRelativePath(node *filterTreeNode, childPath string) {
-	if len(childPath) == 0 {
-		node.shouldAccept = true
-		return
+// Explain evaluates the specified path in the same way as IsAccepted but
+// also reports which rule was the last one to decide the acceptance of the
+// path or any of its parents. A rule is considered to have decided the
+// acceptance if it flipped it or if it overrode a conflicting rule.
+func (t *FilterTree) Explain(path AbsolutePath) FilterDecision {
+	var (
+		current = filterCursor{
+			node: t.root,
+		}
+		decision FilterDecision
+	)
+	visit := func(currentPath, childName string) {
+		wasAccepted := current.accepted
+		var match filterMatch
+		current, match = t.navigateAway(current, childName)
+		if match.rule != nil && (current.accepted != wasAccepted || match.contested) {
+			rule := match.rule.rule
+			decision.Rule = &rule
+			decision.Path = currentPath
+		}
 	}
-	childName, nextChildPath := CutPath(childPath)
-	childNode, ok := node.children[childName]
-	if !ok {
-		childNode = newFilterTreeNode()
-		node.children[childName] = childNode
+	childName, nextChildPath := CutPath(path)
+	currentPath := childName
+	visit(currentPath, childName)
+	for nextChildPath != "" {
+		childName, nextChildPath = CutPath(nextChildPath)
+		currentPath = currentPath + string(filepath.Separator) + childName
+		visit(currentPath, childName)
 	}
-	t.acceptRelativePath(childNode, nextChildPath)
+	decision.Accepted = current.accepted
+	return decision
 }
 
-func (t *FilterTree) rejectRelativePath(node *filterTreeNode, childPath string) {
-	if len(childPath) == 0 {
-		node.shouldReject = true
-		return
-	}
-	childName, nextChildPath := CutPath(childPath)
-	childNode, ok := node.children[childName]
-	if !ok {
-		childNode = newFilterTreeNode()
-		node.children[childName] = childNode
+func (t *FilterTree) nodeAt(path AbsolutePath) *filterTreeNode {
+	childName, nextChildPath := CutPath(path)
+	current := t.root.ensureChild(childName)
+	for nextChildPath != "" {
+		childName, nextChildPath = CutPath(nextChildPath)
+		current = current.ensureChild(childName)
 	}
-	t.rejectRelativePath(childNode, nextChildPath)
+	return current
 }
 
-func (t *FilterTree) findRoots(result *ds.List[string], currentPath string, current *filterTreeNode, isCurrentAccepted bool) {
-	for childName, childNode := range current.children {
+func (t *FilterTree) findRoots(result *ds.List[string], currentPath string, current filterCursor) {
+	if current.node == nil {
+		return
+	}
+	for childName := range current.node.children {
 		childPath := fmt.Sprintf("%s%s%s", currentPath, string(filepath.Separator), childName)
-		_, isChildAccepted := t.navigateAway(current, isCurrentAccepted, childName)
-		if isChildAccepted && !isCurrentAccepted {
+		childCursor, _ := t.navigateAway(current, childName)
+		if childCursor.accepted && !current.accepted {
 			result.Add(childPath)
 		}
-		t.findRoots(result, childPath, childNode, isChildAccepted)
+		t.findRoots(result, childPath, childCursor)
 	}
 }
 
-func (t *FilterTree) navigateAway(current *filterTreeNode, isCurrentAccepted bool, childName string) (*filterTreeNode, bool) {
+// navigateAway evaluates the child segment of the specified cursor and
+// returns the cursor for the child, as well as information on the rule that
+// matched the child segment, if any.
+func (t *FilterTree) navigateAway(current filterCursor, childName string) (filterCursor, filterMatch) {
 	var (
-		childNode       *filterTreeNode
-		childIsAccepted = isCurrentAccepted
+		childNode *filterTreeNode
+		match     filterMatch
 	)
+	consider := func(candidate *orderedFilterRule) {
+		if match.rule != nil && match.rule.rule.Accept != candidate.rule.Accept {
+			match.contested = true
+		}
+		if match.rule == nil || candidate.order > match.rule.order {
+			match.rule = candidate
+		}
+	}
 	// try and get a child node
-	if current != nil {
-		childNode = current.children[childName]
+	if current.node != nil {
+		childNode = current.node.children[childName]
 	}
 	// check path rules
-	if childNode != nil {
-		if childNode.shouldReject {
-			childIsAccepted = false
+	if childNode != nil && childNode.pathRule != nil {
+		consider(childNode.pathRule)
+	}
+	// check pattern rules
+	for i := range t.globs {
+		if isSegmentMatch(t.globs[i].rule.Pattern, childName) {
+			consider(&t.globs[i])
 		}
-		if childNode.shouldAccept {
-			childIsAccepted = true
+	}
+	for i := range current.scopedGlobs {
+		if isSegmentMatch(current.scopedGlobs[i].rule.Pattern, childName) {
+			consider(&current.scopedGlobs[i])
 		}
 	}
-	// check pattern rules
-	if t.isSegmentPatternAccepted(childName) {
-		childIsAccepted = true
+
+	child := filterCursor{
+		node:        childNode,
+		scopedGlobs: current.scopedGlobs,
+		accepted:    current.accepted,
 	}
-	if t.isSegmentPatternRejected(childName) {
-		childIsAccepted = false
+	if match.rule != nil {
+		child.accepted = match.rule.rule.Accept
 	}
-	return childNode, childIsAccepted
+	if childNode != nil && len(childNode.scopedGlobs) > 0 {
+		// Make sure that sibling paths don't share the backing array.
+		child.scopedGlobs = append(slices.Clip(current.scopedGlobs), childNode.scopedGlobs...)
+	}
+	return child, match
 }
 
-func (t *FilterTree) isSegmentPatternAccepted(segment string) bool {
-	for _, pattern := range t.acceptPatterns {
-		if ok, err := filepath.Match(pattern, segment); err == nil && ok {
-			return true
-		}
-	}
-	return false
+func isSegmentMatch(pattern, segment string) bool {
+	ok, err := filepath.Match(pattern, segment)
+	return err == nil && ok
 }
 
-func (t *FilterTree) isSegmentPatternRejected(segment string) bool {
-	for _, pattern := range t.rejectPatterns {
-		if ok, err := filepath.Match(pattern, segment); err == nil && ok {
-			return true
-		}
-	}
-	return false
+// FilterDecision describes the outcome of evaluating a path against
+// a FilterTree.
+type FilterDecision struct {
+
+	// Accepted indicates whether the path is accepted by the filter.
+	Accepted bool
+
+	// Rule is the rule that last decided the acceptance state. It is nil if
+	// no rule ever decided the state, in which case the path is rejected
+	// by default.
+	Rule *FilterRule
+
+	// Path is the path prefix at which Rule was applied.
+	Path AbsolutePath
+}
+
+type orderedFilterRule struct {
+	order int
+	rule  FilterRule
+}
+
+type filterMatch struct {
+	rule      *orderedFilterRule
+	contested bool
+}
+
+type filterCursor struct {
+	node        *filterTreeNode
+	scopedGlobs []orderedFilterRule
+	accepted    bool
 }
 
 func newFilterTreeNode() *filterTreeNode {
@@ -172,7 +293,16 @@ func newFilterTreeNode() *filterTreeNode {
 }
 
 type filterTreeNode struct {
-	children     map[string]*filterTreeNode
-	shouldAccept bool
-	shouldReject bool
+	children    map[string]*filterTreeNode
+	pathRule    *orderedFilterRule
+	scopedGlobs []orderedFilterRule
+}
+
+func (n *filterTreeNode) ensureChild(name string) *filterTreeNode {
+	childNode, ok := n.children[name]
+	if !ok {
+		childNode = newFilterTreeNode()
+		n.children[name] = childNode
+	}
+	return childNode
 }
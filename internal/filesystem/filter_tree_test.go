@@ -72,4 +72,129 @@ var _ = Describe("FilterTree", func() {
 			"/users/john/documents/memos",
 		))
 	})
+
+	Specify("later rules supersede earlier rules", func() {
+		tree.AcceptGlob(filesystem.Glob("*_test.go"))
+		Expect(tree.IsAccepted("/users/jane/data_test.go")).To(BeTrue())
+
+		tree.RejectPath("/users/jane")
+		Expect(tree.IsAccepted("/users/jane/data_test.go")).To(BeTrue())
+		Expect(tree.IsAccepted("/users/jane/data.go")).To(BeFalse())
+	})
+
+	Specify("scoped globs only apply to sub-paths of their path", func() {
+		tree.AddRule(filesystem.FilterRule{
+			Accept:  true,
+			Path:    "/users/jane/testsupport",
+			Pattern: "*_test.go",
+		})
+		Expect(tree.IsAccepted("/users/jane/testsupport/data_test.go")).To(BeTrue())
+		Expect(tree.IsAccepted("/users/jane/testsupport/nested/data_test.go")).To(BeTrue())
+		Expect(tree.IsAccepted("/users/jane/data_test.go")).To(BeFalse())
+		Expect(tree.IsAccepted("/users/jane/testsupport_test.go")).To(BeFalse())
+	})
+
+	Specify("default rules are superseded by all other rules", func() {
+		tree.AddDefaultRule(filesystem.FilterRule{
+			Accept:  true,
+			Path:    "/modules/lib",
+			Pattern: "*.go",
+		})
+		tree.AddDefaultRule(filesystem.FilterRule{
+			Accept:  false,
+			Path:    "/modules/lib",
+			Pattern: "*_gen.go",
+		})
+		tree.AddDefaultRule(filesystem.FilterRule{
+			Accept: false,
+			Path:   "/users/max",
+		})
+		Expect(tree.IsAccepted("/modules/lib/lib.go")).To(BeTrue())
+		Expect(tree.IsAccepted("/modules/lib/lib_gen.go")).To(BeFalse())
+		Expect(tree.IsAccepted("/modules/lib/lib_test.go")).To(BeFalse())
+		Expect(tree.IsAccepted("/modules/other.go")).To(BeFalse())
+		Expect(tree.IsAccepted("/users/max/some_important_items")).To(BeTrue())
+
+		tree.RejectGlob(filesystem.Glob("lib.go"))
+		Expect(tree.IsAccepted("/modules/lib/lib.go")).To(BeFalse())
+	})
+
+	Describe("Explain", func() {
+		It("reports no rule for paths that were never accepted", func() {
+			Expect(tree.Explain("/tmp/data")).To(Equal(filesystem.FilterDecision{
+				Accepted: false,
+			}))
+		})
+
+		It("reports the path rule that accepted a parent path", func() {
+			Expect(tree.Explain("/users/jane/notes.txt")).To(Equal(filesystem.FilterDecision{
+				Accepted: true,
+				Rule: &filesystem.FilterRule{
+					Accept: true,
+					Path:   "/users",
+				},
+				Path: "/users",
+			}))
+		})
+
+		It("reports the deepest path rule that flipped acceptance", func() {
+			Expect(tree.Explain("/users/john/documents/memos/work")).To(Equal(filesystem.FilterDecision{
+				Accepted: true,
+				Rule: &filesystem.FilterRule{
+					Accept: true,
+					Path:   "/users/john/documents/memos",
+				},
+				Path: "/users/john/documents/memos",
+			}))
+			Expect(tree.Explain("/users/john/documents/videos")).To(Equal(filesystem.FilterDecision{
+				Accepted: false,
+				Rule: &filesystem.FilterRule{
+					Accept: false,
+					Path:   "/users/john/documents",
+				},
+				Path: "/users/john/documents",
+			}))
+		})
+
+		It("reports the glob that flipped acceptance", func() {
+			Expect(tree.Explain("/users/jane/data_test.go")).To(Equal(filesystem.FilterDecision{
+				Accepted: false,
+				Rule: &filesystem.FilterRule{
+					Accept:  false,
+					Pattern: "*_test.go",
+				},
+				Path: "/users/jane/data_test.go",
+			}))
+			Expect(tree.Explain("/users/max/some_important_items")).To(Equal(filesystem.FilterDecision{
+				Accepted: true,
+				Rule: &filesystem.FilterRule{
+					Accept:  true,
+					Pattern: "*important*",
+				},
+				Path: "/users/max/some_important_items",
+			}))
+		})
+
+		It("reports rules that overrode conflicting rules", func() {
+			Expect(tree.Explain("/users/jane/important_test.go")).To(Equal(filesystem.FilterDecision{
+				Accepted: false,
+				Rule: &filesystem.FilterRule{
+					Accept:  false,
+					Pattern: "*_test.go",
+				},
+				Path: "/users/jane/important_test.go",
+			}))
+		})
+
+		It("ignores rules that did not change the outcome", func() {
+			Expect(tree.Explain("/users/jane/important")).To(Equal(filesystem.FilterDecision{
+				Accepted: true,
+				Rule: &filesystem.FilterRule{
+					Accept: true,
+					Path:   "/users",
+				},
+				Path: "/users",
+			}))
+		})
+	})
 })
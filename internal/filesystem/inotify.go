@@ -0,0 +1,91 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// inotifyWatchLimitFile holds the maximum number of inotify watches that
+	// a user can have on Linux.
+	inotifyWatchLimitFile = "/proc/sys/fs/inotify/max_user_watches"
+
+	// inotifyFDTarget is the link target of file descriptors that refer to
+	// an inotify instance.
+	inotifyFDTarget = "anon_inode:inotify"
+)
+
+// InotifyWatchLimit returns the maximum number of inotify watches that the
+// current user can have. It returns zero on systems that do not provide
+// this information.
+func InotifyWatchLimit() (int, error) {
+	data, err := os.ReadFile(inotifyWatchLimitFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %q: %w", inotifyWatchLimitFile, err)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("malformed watch limit %q: %w", data, err)
+	}
+	return limit, nil
+}
+
+// InotifyWatchUsage returns the number of inotify watches that are held by
+// the processes that the current process is allowed to inspect, which
+// usually are the processes of the current user. Processes that exit or
+// cannot be inspected in the meantime are skipped.
+func InotifyWatchUsage() (int, error) {
+	fdDirs, err := filepath.Glob("/proc/[0-9]*/fd")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list processes: %w", err)
+	}
+	var result int
+	for _, fdDir := range fdDirs {
+		entries, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			target, err := os.Readlink(filepath.Join(fdDir, entry.Name()))
+			if err != nil || target != inotifyFDTarget {
+				continue
+			}
+			fdInfoFile := filepath.Join(filepath.Dir(fdDir), "fdinfo", entry.Name())
+			file, err := os.Open(fdInfoFile)
+			if err != nil {
+				continue
+			}
+			count, err := CountInotifyWatches(file)
+			file.Close()
+			if err != nil {
+				continue
+			}
+			result += count
+		}
+	}
+	return result, nil
+}
+
+// CountInotifyWatches returns the number of watches that are listed in
+// input in the format of /proc/<pid>/fdinfo/<fd> for an inotify instance.
+func CountInotifyWatches(in io.Reader) (int, error) {
+	var result int
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "inotify wd:") {
+			result++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read fdinfo: %w", err)
+	}
+	return result, nil
+}
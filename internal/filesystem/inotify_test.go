@@ -0,0 +1,27 @@
+package filesystem_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+var _ = Describe("Inotify", func() {
+	It("counts watches of an inotify instance", func() {
+		const fdInfo = "" +
+			"pos:\t0\n" +
+			"flags:\t02004000\n" +
+			"mnt_id:\t15\n" +
+			"ino:\t1057\n" +
+			"inotify wd:2 ino:1a2b sdev:800001 mask:fc6 ignored_mask:0 fhandle-bytes:8 fhandle-type:1 f_handle:2b1a000000000000\n" +
+			"inotify wd:1 ino:2 sdev:800001 mask:fc6 ignored_mask:0 fhandle-bytes:8 fhandle-type:1 f_handle:0200000000000000\n"
+		Expect(filesystem.CountInotifyWatches(strings.NewReader(fdInfo))).To(Equal(2))
+	})
+
+	It("counts no watches when there are none", func() {
+		Expect(filesystem.CountInotifyWatches(strings.NewReader("pos:\t0\nflags:\t02004000\n"))).To(Equal(0))
+	})
+})
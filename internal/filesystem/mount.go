@@ -0,0 +1,90 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// mountInfoFile lists the mounts that are visible to the current process
+// on Linux.
+const mountInfoFile = "/proc/self/mountinfo"
+
+// MountPoints returns the mount points that are visible to the current
+// process. It returns no mount points on systems that do not provide this
+// information.
+func MountPoints() ([]AbsolutePath, error) {
+	file, err := os.Open(mountInfoFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", mountInfoFile, err)
+	}
+	defer file.Close()
+	return ParseMountInfo(file)
+}
+
+// ParseMountInfo extracts the mount points from input in the format of
+// /proc/self/mountinfo.
+func ParseMountInfo(in io.Reader) ([]AbsolutePath, error) {
+	var result []AbsolutePath
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		// The mount point is the fifth field. Fields cannot contain spaces,
+		// as those are escaped in octal form.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("malformed mount info line %q", scanner.Text())
+		}
+		result = append(result, unescapeMountPath(fields[4]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mount info: %w", err)
+	}
+	return result, nil
+}
+
+// MountPointOf returns the mount point from the specified ones that holds
+// the specified path, which is the deepest one that contains it.
+func MountPointOf(path AbsolutePath, mountPoints []AbsolutePath) AbsolutePath {
+	var result AbsolutePath
+	for _, mountPoint := range mountPoints {
+		if isPathWithin(path, mountPoint) && len(mountPoint) > len(result) {
+			result = mountPoint
+		}
+	}
+	return result
+}
+
+func isPathWithin(path, dir AbsolutePath) bool {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+// unescapeMountPath decodes the octal escape sequences (e.g. \040 for
+// space) that are used in mount info paths.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var builder strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+4 <= len(path) {
+			if value, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				builder.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(path[i])
+	}
+	return builder.String()
+}
@@ -0,0 +1,40 @@
+package filesystem_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+var _ = Describe("Mount", func() {
+	const mountInfo = "" +
+		"123 100 0:50 / / rw,relatime master:1 - overlay overlay rw\n" +
+		"124 123 0:51 / /proc rw,nosuid - proc proc rw\n" +
+		"130 123 8:1 /home/user/project /app rw,relatime - ext4 /dev/sda1 rw\n" +
+		"131 123 8:1 /home/user/my\\040shared /my\\040shared rw,relatime - ext4 /dev/sda1 rw\n"
+
+	It("parses mount points", func() {
+		Expect(filesystem.ParseMountInfo(strings.NewReader(mountInfo))).To(Equal([]string{
+			"/",
+			"/proc",
+			"/app",
+			"/my shared",
+		}))
+	})
+
+	It("reports malformed input", func() {
+		_, err := filesystem.ParseMountInfo(strings.NewReader("123 100\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("finds the deepest mount point of a path", func() {
+		mountPoints := []string{"/", "/app", "/app/vendor"}
+		Expect(filesystem.MountPointOf("/app/internal", mountPoints)).To(Equal("/app"))
+		Expect(filesystem.MountPointOf("/app/vendor/lib", mountPoints)).To(Equal("/app/vendor"))
+		Expect(filesystem.MountPointOf("/application", mountPoints)).To(Equal("/"))
+		Expect(filesystem.MountPointOf("/app", mountPoints)).To(Equal("/app"))
+	})
+})
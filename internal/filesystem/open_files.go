@@ -0,0 +1,34 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// OpenFiles returns the paths of the files that the process with the
+// specified PID currently has open. Other kinds of descriptors, such as
+// sockets and pipes, as well as deleted files, are not included.
+//
+// This is only supported on systems that provide /proc/<pid>/fd.
+func OpenFiles(pid int) ([]AbsolutePath, error) {
+	fdDir := filepath.Join("/proc", strconv.Itoa(pid), "fd")
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list descriptors of process %d: %w", pid, err)
+	}
+	var result []AbsolutePath
+	for _, entry := range entries {
+		target, err := os.Readlink(filepath.Join(fdDir, entry.Name()))
+		if err != nil {
+			continue // closed in the meantime
+		}
+		if !filepath.IsAbs(target) || strings.HasSuffix(target, " (deleted)") {
+			continue
+		}
+		result = append(result, target)
+	}
+	return result, nil
+}
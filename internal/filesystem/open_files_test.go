@@ -0,0 +1,46 @@
+package filesystem_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+var _ = Describe("OpenFiles", func() {
+	BeforeEach(func() {
+		if runtime.GOOS != "linux" {
+			Skip("open files are only available on Linux")
+		}
+	})
+
+	It("lists the files that are open", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(path, nil, 0o644)).To(Succeed())
+		file, err := os.Open(path)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		realPath, err := filepath.EvalSymlinks(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filesystem.OpenFiles(os.Getpid())).To(ContainElement(realPath))
+	})
+
+	It("does not list files that are closed", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(path, nil, 0o644)).To(Succeed())
+
+		realPath, err := filepath.EvalSymlinks(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filesystem.OpenFiles(os.Getpid())).ToNot(ContainElement(realPath))
+	})
+
+	It("reports missing processes", func() {
+		_, err := filesystem.OpenFiles(-1)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,185 @@
+package filesystem
+
+import (
+	"iter"
+	"path/filepath"
+	"strings"
+)
+
+// NewPathTree creates a new empty PathTree instance.
+func NewPathTree[T any]() *PathTree[T] {
+	return &PathTree[T]{
+		root: newPathTreeNode[T](),
+	}
+}
+
+// PathTree is a data structure that associates values with filesystem
+// paths. Paths are stored by segment, the same way as in a FilterTree, so
+// that all paths below a given path can be found in time proportional to
+// their count rather than to the size of the tree, and so that a path like
+// /src/api is never considered to contain /src/api2.
+type PathTree[T any] struct {
+	root *pathTreeNode[T]
+	size int
+}
+
+// Len returns the number of paths in the tree.
+func (t *PathTree[T]) Len() int {
+	return t.size
+}
+
+// Get returns the value of the specified path and whether the path is
+// in the tree.
+func (t *PathTree[T]) Get(path AbsolutePath) (T, bool) {
+	node := t.lookup(path)
+	if node == nil || !node.hasValue {
+		var zero T
+		return zero, false
+	}
+	return node.value, true
+}
+
+// Contains returns whether the specified path is in the tree.
+func (t *PathTree[T]) Contains(path AbsolutePath) bool {
+	_, ok := t.Get(path)
+	return ok
+}
+
+// Set assigns the specified value to the specified path, adding the path
+// to the tree if needed.
+func (t *PathTree[T]) Set(path AbsolutePath, value T) {
+	childName, nextChildPath := CutPath(path)
+	current := t.root.ensureChild(childName)
+	for nextChildPath != "" {
+		childName, nextChildPath = CutPath(nextChildPath)
+		current = current.ensureChild(childName)
+	}
+	if !current.hasValue {
+		t.size++
+	}
+	current.value = value
+	current.hasValue = true
+}
+
+// Delete removes the specified path from the tree. Paths below it are
+// kept. It returns whether the path was in the tree.
+func (t *PathTree[T]) Delete(path AbsolutePath) bool {
+	// The nodes along the path are remembered, so that the ones that
+	// are left without values and children can be pruned.
+	nodes := []*pathTreeNode[T]{t.root}
+	var names []string
+	childName, nextChildPath := CutPath(path)
+	for {
+		node := nodes[len(nodes)-1].children[childName]
+		if node == nil {
+			return false
+		}
+		nodes = append(nodes, node)
+		names = append(names, childName)
+		if nextChildPath == "" {
+			break
+		}
+		childName, nextChildPath = CutPath(nextChildPath)
+	}
+
+	node := nodes[len(nodes)-1]
+	if !node.hasValue {
+		return false
+	}
+	var zero T
+	node.value = zero
+	node.hasValue = false
+	t.size--
+
+	for i := len(nodes) - 1; i > 0; i-- {
+		if nodes[i].hasValue || len(nodes[i].children) > 0 {
+			break
+		}
+		delete(nodes[i-1].children, names[i-1])
+	}
+	return true
+}
+
+// All returns all paths in the tree along with their values. The order of
+// the paths is not defined. The tree must not be modified during iteration.
+func (t *PathTree[T]) All() iter.Seq2[AbsolutePath, T] {
+	return func(yield func(AbsolutePath, T) bool) {
+		for childName, child := range t.root.children {
+			if !child.yield(childName, yield) {
+				return
+			}
+		}
+	}
+}
+
+// Subtree returns the specified path and all paths below it that are in
+// the tree, along with their values. The order of the paths is not defined.
+// The tree must not be modified during iteration.
+func (t *PathTree[T]) Subtree(path AbsolutePath) iter.Seq2[AbsolutePath, T] {
+	return func(yield func(AbsolutePath, T) bool) {
+		node := t.lookup(path)
+		if node == nil {
+			return
+		}
+		if node.hasValue && !yield(path, node.value) {
+			return
+		}
+		node.yieldChildren(strings.TrimSuffix(path, string(filepath.Separator)), yield)
+	}
+}
+
+func (t *PathTree[T]) lookup(path AbsolutePath) *pathTreeNode[T] {
+	childName, nextChildPath := CutPath(path)
+	current := t.root.children[childName]
+	for current != nil && nextChildPath != "" {
+		childName, nextChildPath = CutPath(nextChildPath)
+		current = current.children[childName]
+	}
+	return current
+}
+
+func newPathTreeNode[T any]() *pathTreeNode[T] {
+	return &pathTreeNode[T]{
+		children: make(map[string]*pathTreeNode[T]),
+	}
+}
+
+type pathTreeNode[T any] struct {
+	children map[string]*pathTreeNode[T]
+	value    T
+	hasValue bool
+}
+
+func (n *pathTreeNode[T]) ensureChild(name string) *pathTreeNode[T] {
+	childNode, ok := n.children[name]
+	if !ok {
+		childNode = newPathTreeNode[T]()
+		n.children[name] = childNode
+	}
+	return childNode
+}
+
+// yield reports the node, whose path is built by joining segments with the
+// separator, and all nodes below it. The path of a node is empty for the
+// filesystem root, which is reported as the separator instead.
+func (n *pathTreeNode[T]) yield(path string, yield func(AbsolutePath, T) bool) bool {
+	if n.hasValue {
+		reported := path
+		if reported == "" {
+			reported = string(filepath.Separator)
+		}
+		if !yield(reported, n.value) {
+			return false
+		}
+	}
+	return n.yieldChildren(path, yield)
+}
+
+func (n *pathTreeNode[T]) yieldChildren(path string, yield func(AbsolutePath, T) bool) bool {
+	for childName, child := range n.children {
+		if !child.yield(path+string(filepath.Separator)+childName, yield) {
+			return false
+		}
+	}
+	return true
+}
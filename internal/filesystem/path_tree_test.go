@@ -0,0 +1,111 @@
+package filesystem_test
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+var _ = Describe("PathTree", func() {
+	var tree *filesystem.PathTree[int]
+
+	BeforeEach(func() {
+		tree = filesystem.NewPathTree[int]()
+		tree.Set("/src", 1)
+		tree.Set("/src/api", 2)
+		tree.Set("/src/api/handler.go", 3)
+		tree.Set("/src/api2", 4)
+		tree.Set("/src/api2/handler.go", 5)
+	})
+
+	It("stores values by path", func() {
+		Expect(tree.Len()).To(Equal(5))
+		value, ok := tree.Get("/src/api/handler.go")
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal(3))
+		Expect(tree.Contains("/src/api2")).To(BeTrue())
+		Expect(tree.Contains("/src/ap")).To(BeFalse())
+		Expect(tree.Contains("/src/api/handler.go/x")).To(BeFalse())
+	})
+
+	It("replaces values", func() {
+		tree.Set("/src/api", 20)
+		Expect(tree.Len()).To(Equal(5))
+		value, _ := tree.Get("/src/api")
+		Expect(value).To(Equal(20))
+	})
+
+	It("does not report intermediate segments as paths", func() {
+		tree.Set("/other/deep/file.go", 6)
+		Expect(tree.Contains("/other")).To(BeFalse())
+		Expect(tree.Contains("/other/deep")).To(BeFalse())
+		Expect(maps.Collect(tree.All())).To(HaveLen(6))
+	})
+
+	It("deletes paths but keeps the paths below them", func() {
+		Expect(tree.Delete("/src/api")).To(BeTrue())
+		Expect(tree.Delete("/src/api")).To(BeFalse())
+		Expect(tree.Delete("/src/missing")).To(BeFalse())
+		Expect(tree.Len()).To(Equal(4))
+		Expect(tree.Contains("/src/api")).To(BeFalse())
+		Expect(tree.Contains("/src/api/handler.go")).To(BeTrue())
+	})
+
+	It("lists all paths", func() {
+		Expect(maps.Collect(tree.All())).To(Equal(map[string]int{
+			"/src":                 1,
+			"/src/api":             2,
+			"/src/api/handler.go":  3,
+			"/src/api2":            4,
+			"/src/api2/handler.go": 5,
+		}))
+	})
+
+	It("lists the paths of a subtree, respecting segment boundaries", func() {
+		Expect(maps.Collect(tree.Subtree("/src/api"))).To(Equal(map[string]int{
+			"/src/api":            2,
+			"/src/api/handler.go": 3,
+		}))
+		Expect(maps.Collect(tree.Subtree("/src/ap"))).To(BeEmpty())
+		Expect(maps.Collect(tree.Subtree("/src/api/"))).To(HaveLen(2))
+	})
+
+	It("supports the filesystem root", func() {
+		tree.Set("/", 0)
+		Expect(tree.Contains("/")).To(BeTrue())
+		Expect(maps.Collect(tree.All())).To(HaveKeyWithValue("/", 0))
+		Expect(maps.Collect(tree.Subtree("/"))).To(HaveLen(6))
+	})
+
+	It("handles mass deletes of large trees", func() {
+		const root = "/repo/node_modules"
+		var paths []string
+		for i := range 100 {
+			pkg := filepath.Join(root, fmt.Sprintf("pkg%d", i))
+			paths = append(paths, pkg)
+			for j := range 100 {
+				paths = append(paths, filepath.Join(pkg, fmt.Sprintf("file%d.js", j)))
+			}
+		}
+		tree.Set(root, 0)
+		for _, path := range paths {
+			tree.Set(path, 1)
+		}
+		tree.Set("/repo/node_modules2/file.js", 2)
+		Expect(tree.Len()).To(Equal(5 + 1 + len(paths) + 1))
+
+		subtree := maps.Collect(tree.Subtree(root))
+		Expect(subtree).To(HaveLen(1 + len(paths)))
+		for path := range subtree {
+			Expect(tree.Delete(path)).To(BeTrue())
+		}
+		Expect(tree.Len()).To(Equal(5 + 1))
+		Expect(maps.Collect(tree.Subtree(root))).To(BeEmpty())
+		Expect(tree.Contains("/repo/node_modules2/file.js")).To(BeTrue())
+	})
+})
@@ -0,0 +1,34 @@
+package filesystem
+
+import (
+	"io/fs"
+	"sync"
+)
+
+// StatFunc returns information on the file at the specified path, like
+// os.Stat or os.Lstat.
+type StatFunc func(path string) (fs.FileInfo, error)
+
+// StatAll calls stat for each of the specified paths and returns the
+// results in the same order as the paths. The calls are made concurrently,
+// since each of them can have a high latency on network-backed filesystems.
+func StatAll(paths []string, stat StatFunc) ([]fs.FileInfo, []error) {
+	infos := make([]fs.FileInfo, len(paths))
+	errs := make([]error, len(paths))
+
+	indices := make(chan int)
+	var workers sync.WaitGroup
+	for range min(traverseWorkers, len(paths)) {
+		workers.Go(func() {
+			for i := range indices {
+				infos[i], errs[i] = stat(paths[i])
+			}
+		})
+	}
+	for i := range paths {
+		indices <- i
+	}
+	close(indices)
+	workers.Wait()
+	return infos, errs
+}
@@ -0,0 +1,39 @@
+package filesystem_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+var _ = Describe("StatAll", func() {
+	It("returns the results in the order of the paths", func() {
+		dir := GinkgoT().TempDir()
+		var paths []string
+		for _, name := range []string{"a", "missing", "bb", "ccc"} {
+			path := filepath.Join(dir, name)
+			if name != "missing" {
+				Expect(os.WriteFile(path, []byte(name), 0o644)).To(Succeed())
+			}
+			paths = append(paths, path)
+		}
+
+		infos, errs := filesystem.StatAll(paths, os.Stat)
+		Expect(infos).To(HaveLen(4))
+		Expect(errs).To(HaveLen(4))
+		Expect(infos[0].Size()).To(Equal(int64(1)))
+		Expect(errs[1]).To(MatchError(os.ErrNotExist))
+		Expect(infos[2].Size()).To(Equal(int64(2)))
+		Expect(infos[3].Size()).To(Equal(int64(3)))
+	})
+
+	It("supports no paths", func() {
+		infos, errs := filesystem.StatAll(nil, os.Stat)
+		Expect(infos).To(BeEmpty())
+		Expect(errs).To(BeEmpty())
+	})
+})
@@ -5,8 +5,15 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
+// traverseWorkers is the maximum number of directories that are read
+// concurrently during a traversal. Reading directories is bound by I/O
+// latency rather than CPU, especially on network-backed filesystems, so
+// this does not depend on the number of CPUs.
+const traverseWorkers = 16
+
 // ErrSkip can be returned to indicate that the current file or folder
 // should be skipped from any further traversal.
 var ErrSkip = fmt.Errorf("skip file or folder")
@@ -16,31 +23,253 @@ type TraverseFunc func(path string, isDir bool, err error) error
 
 // Traverse attempts to simplify file traversal.
 //
-// The underlying filepath.WalkDir only works well for directories as
-// root elements. Passing a file as root changes the contract substantially
-// making it hard on the client.
+// Unlike filepath.WalkDir, the root may also be a file, in which case it is
+// the only path that is reported. Symlinks are reported as files and are not
+// descended into.
+//
+// Directories are read concurrently, but the callback is always called from
+// the calling goroutine and in a deterministic order: all entries of a
+// directory are reported, sorted by name, before any of its subdirectories
+// are descended into. A directory is only read after the callback has
+// accepted it.
 func Traverse(root string, callback TraverseFunc) {
+	traverse(root, os.ReadDir, callback)
+}
+
+func traverse(root string, readDir readDirFunc, callback TraverseFunc) {
 	// Handle the case where the root path is a file.
 	info, err := os.Lstat(root)
 	if err != nil {
 		callback(root, false, fmt.Errorf("error getting info on root path %q: %w", root, err))
+		return
+	}
+	if !info.IsDir() {
+		callback(root, false, nil)
+		return
+	}
+	if err := callback(root, true, nil); err != nil {
+		return
+	}
+	t := newTraverser(readDir, false, callback)
+	defer t.stop()
+	t.walk(newDirListing(root, "", nil))
+}
+
+// ErrSymlinkCycle indicates that a symlinked directory points to one of
+// the directories that contain it.
+var ErrSymlinkCycle = fmt.Errorf("symlink cycle")
+
+// TraverseSymlinks works like Traverse but also descends into directories
+// that are referenced through symlinks. Paths are reported as seen through
+// the symlinks and not as the real paths that they resolve to.
+//
+// A symlinked directory that resolves to one of its own ancestors is
+// reported with ErrSymlinkCycle and is not descended into. Broken symlinks
+// are reported as files.
+func TraverseSymlinks(root string, callback TraverseFunc) {
+	traverseSymlinks(root, os.ReadDir, callback)
+}
+
+func traverseSymlinks(root string, readDir readDirFunc, callback TraverseFunc) {
+	info, err := os.Stat(root)
+	if err != nil {
+		if linkInfo, linkErr := os.Lstat(root); linkErr == nil && linkInfo.Mode()&fs.ModeSymlink != 0 {
+			callback(root, false, nil)
+			return
+		}
+		callback(root, false, fmt.Errorf("error getting info on path %q: %w", root, err))
+		return
 	}
 	if !info.IsDir() {
-		callback(root, info.IsDir(), nil)
+		callback(root, false, nil)
+		return
+	}
+	realPath, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		callback(root, true, fmt.Errorf("error resolving path %q: %w", root, err))
+		return
+	}
+	if err := callback(root, true, nil); err != nil {
+		return
+	}
+	t := newTraverser(readDir, true, callback)
+	defer t.stop()
+	t.walk(newDirListing(root, realPath, nil))
+}
+
+// readDirFunc lists the entries of a directory, sorted by name.
+type readDirFunc func(path string) ([]fs.DirEntry, error)
+
+func newTraverser(readDir readDirFunc, followSymlinks bool, callback TraverseFunc) *traverser {
+	t := &traverser{
+		readDir:        readDir,
+		followSymlinks: followSymlinks,
+		callback:       callback,
+	}
+	t.cond = sync.NewCond(&t.mu)
+	for range traverseWorkers {
+		t.workers.Go(t.work)
+	}
+	return t
+}
+
+// traverser reads directories on a pool of workers, while the traversal
+// itself and all callbacks happen on the goroutine that calls walk.
+type traverser struct {
+	readDir        readDirFunc
+	followSymlinks bool
+	callback       TraverseFunc
+
+	workers sync.WaitGroup
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*dirListing
+	stopped bool
+}
+
+func (t *traverser) walk(listing *dirListing) {
+	listing.load(t)
+	if listing.err != nil {
+		t.callback(listing.path, true, listing.err)
+		return
+	}
+
+	var children []*dirListing
+	for _, entry := range listing.entries {
+		if entry.err != nil {
+			t.callback(entry.path, entry.isDir, entry.err)
+			continue
+		}
+		if err := t.callback(entry.path, entry.isDir, nil); err != nil || !entry.isDir {
+			continue
+		}
+		children = append(children, newDirListing(entry.path, entry.realPath, listing))
+	}
+
+	t.schedule(children)
+	for _, child := range children {
+		t.walk(child)
+	}
+}
+
+// schedule queues the specified listings for the workers. The pending
+// listings are processed last in, first out, so they are queued in reverse,
+// which has the workers read ahead of the traversal in the order in which
+// the listings will be needed.
+func (t *traverser) schedule(listings []*dirListing) {
+	if len(listings) == 0 {
 		return
 	}
+	t.mu.Lock()
+	for i := len(listings) - 1; i >= 0; i-- {
+		t.pending = append(t.pending, listings[i])
+	}
+	t.mu.Unlock()
+	t.cond.Broadcast()
+}
+
+func (t *traverser) work() {
+	for {
+		t.mu.Lock()
+		for len(t.pending) == 0 && !t.stopped {
+			t.cond.Wait()
+		}
+		if t.stopped {
+			t.mu.Unlock()
+			return
+		}
+		listing := t.pending[len(t.pending)-1]
+		t.pending = t.pending[:len(t.pending)-1]
+		t.mu.Unlock()
+
+		listing.load(t)
+	}
+}
+
+func (t *traverser) stop() {
+	t.mu.Lock()
+	t.stopped = true
+	t.pending = nil
+	t.mu.Unlock()
+	t.cond.Broadcast()
+	t.workers.Wait()
+}
 
-	// The root is a dir so we can use filepath.WalkDir now.
-	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
-		// The only case where `d` is nil is when the root is a file. Since we
-		// have covered this case already, we can use it at will.
-		if cbErr := callback(p, d.IsDir(), err); cbErr != nil || err != nil {
-			if d.IsDir() {
-				return filepath.SkipDir
-			} else {
-				return nil
+func newDirListing(path, realPath string, parent *dirListing) *dirListing {
+	return &dirListing{
+		path:     path,
+		realPath: realPath,
+		parent:   parent,
+	}
+}
+
+// dirListing holds the entries of a directory. It is loaded by whichever
+// comes first, a worker or the traversal that needs it.
+type dirListing struct {
+	path     string
+	realPath string
+	parent   *dirListing
+
+	once    sync.Once
+	entries []dirListingEntry
+	err     error
+}
+
+type dirListingEntry struct {
+	path     string
+	realPath string
+	isDir    bool
+	err      error
+}
+
+func (l *dirListing) load(t *traverser) {
+	l.once.Do(func() {
+		entries, err := t.readDir(l.path)
+		if err != nil {
+			if t.followSymlinks {
+				err = fmt.Errorf("error reading dir %q: %w", l.path, err)
 			}
+			l.err = err
+			return
+		}
+		l.entries = make([]dirListingEntry, len(entries))
+		for i, entry := range entries {
+			l.entries[i] = l.resolve(entry, t.followSymlinks)
 		}
-		return nil
 	})
 }
+
+func (l *dirListing) resolve(entry fs.DirEntry, followSymlinks bool) dirListingEntry {
+	result := dirListingEntry{
+		path:  filepath.Join(l.path, entry.Name()),
+		isDir: entry.IsDir(),
+	}
+	if !followSymlinks {
+		return result
+	}
+	if entry.Type()&fs.ModeSymlink == 0 {
+		if result.isDir {
+			result.realPath = filepath.Join(l.realPath, entry.Name())
+		}
+		return result
+	}
+
+	info, err := os.Stat(result.path)
+	if err != nil || !info.IsDir() {
+		return result // broken symlinks are reported as files
+	}
+	result.isDir = true
+	realPath, err := filepath.EvalSymlinks(result.path)
+	if err != nil {
+		result.err = fmt.Errorf("error resolving path %q: %w", result.path, err)
+		return result
+	}
+	for ancestor := l; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor.realPath == realPath {
+			result.err = fmt.Errorf("%w: %q resolves to %q", ErrSymlinkCycle, result.path, realPath)
+			return result
+		}
+	}
+	result.realPath = realPath
+	return result
+}
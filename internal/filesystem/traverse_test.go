@@ -0,0 +1,143 @@
+package filesystem_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+var _ = Describe("Traverse", func() {
+	var (
+		rootDir   string
+		sharedDir string
+		visited   []string
+		errored   map[string]error
+	)
+
+	callback := func(path string, isDir bool, err error) error {
+		if err != nil {
+			errored[path] = err
+			return filesystem.ErrSkip
+		}
+		visited = append(visited, path)
+		return nil
+	}
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		rootDir = filepath.Join(tempDir, "project")
+		sharedDir = filepath.Join(tempDir, "shared")
+		Expect(os.MkdirAll(filepath.Join(rootDir, "cmd"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(rootDir, "cmd", "main.go"), nil, 0o644)).To(Succeed())
+		Expect(os.MkdirAll(sharedDir, 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(sharedDir, "shared.go"), nil, 0o644)).To(Succeed())
+		Expect(os.Symlink(sharedDir, filepath.Join(rootDir, "shared"))).To(Succeed())
+		visited = nil
+		errored = make(map[string]error)
+	})
+
+	It("does not follow symlinked directories", func() {
+		filesystem.Traverse(rootDir, callback)
+		Expect(visited).To(ConsistOf(
+			rootDir,
+			filepath.Join(rootDir, "cmd"),
+			filepath.Join(rootDir, "cmd", "main.go"),
+			filepath.Join(rootDir, "shared"),
+		))
+		Expect(errored).To(BeEmpty())
+	})
+
+	It("reports a missing root", func() {
+		missingDir := filepath.Join(rootDir, "missing")
+		filesystem.Traverse(missingDir, callback)
+		Expect(visited).To(BeEmpty())
+		Expect(errored).To(HaveKey(missingDir))
+	})
+
+	It("reports paths in a deterministic order", func() {
+		for _, name := range []string{"b", "a", "c"} {
+			dir := filepath.Join(rootDir, "pkg", name)
+			Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "file.go"), nil, 0o644)).To(Succeed())
+		}
+		filesystem.Traverse(rootDir, callback)
+		Expect(visited).To(Equal([]string{
+			rootDir,
+			filepath.Join(rootDir, "cmd"),
+			filepath.Join(rootDir, "pkg"),
+			filepath.Join(rootDir, "shared"),
+			filepath.Join(rootDir, "cmd", "main.go"),
+			filepath.Join(rootDir, "pkg", "a"),
+			filepath.Join(rootDir, "pkg", "b"),
+			filepath.Join(rootDir, "pkg", "c"),
+			filepath.Join(rootDir, "pkg", "a", "file.go"),
+			filepath.Join(rootDir, "pkg", "b", "file.go"),
+			filepath.Join(rootDir, "pkg", "c", "file.go"),
+		}))
+	})
+
+	It("does not descend into skipped directories", func() {
+		filesystem.Traverse(rootDir, func(path string, isDir bool, err error) error {
+			Expect(err).ToNot(HaveOccurred())
+			visited = append(visited, path)
+			if filepath.Base(path) == "cmd" {
+				return filesystem.ErrSkip
+			}
+			return nil
+		})
+		Expect(visited).ToNot(ContainElement(filepath.Join(rootDir, "cmd", "main.go")))
+	})
+
+	It("visits all paths of a large tree", func() {
+		var expected []string
+		for i := range 20 {
+			for j := range 20 {
+				dir := filepath.Join(rootDir, "pkg", fmt.Sprintf("p%d", i), fmt.Sprintf("p%d", j))
+				Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+				file := filepath.Join(dir, "file.go")
+				Expect(os.WriteFile(file, nil, 0o644)).To(Succeed())
+				expected = append(expected, file)
+			}
+		}
+		filesystem.Traverse(rootDir, callback)
+		Expect(visited).To(ContainElements(expected))
+		Expect(visited).To(HaveLen(4 + 1 + 20 + 400 + 400))
+	})
+
+	Describe("TraverseSymlinks", func() {
+		It("follows symlinked directories", func() {
+			filesystem.TraverseSymlinks(rootDir, callback)
+			Expect(visited).To(ConsistOf(
+				rootDir,
+				filepath.Join(rootDir, "cmd"),
+				filepath.Join(rootDir, "cmd", "main.go"),
+				filepath.Join(rootDir, "shared"),
+				filepath.Join(rootDir, "shared", "shared.go"),
+			))
+			Expect(errored).To(BeEmpty())
+		})
+
+		It("reports broken symlinks as files", func() {
+			Expect(os.RemoveAll(sharedDir)).To(Succeed())
+			filesystem.TraverseSymlinks(rootDir, callback)
+			Expect(visited).To(ContainElement(filepath.Join(rootDir, "shared")))
+			Expect(errored).To(BeEmpty())
+		})
+
+		It("detects cycles", func() {
+			loopPath := filepath.Join(sharedDir, "loop")
+			Expect(os.Symlink(rootDir, loopPath)).To(Succeed())
+			filesystem.TraverseSymlinks(rootDir, callback)
+			cyclePath := filepath.Join(rootDir, "shared", "loop")
+			Expect(visited).ToNot(ContainElement(cyclePath))
+			Expect(errored).To(HaveKey(cyclePath))
+			Expect(errors.Is(errored[cyclePath], filesystem.ErrSymlinkCycle)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,98 @@
+package logutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+const (
+	// ComponentKey is the attribute key that identifies the origin of
+	// a log record.
+	ComponentKey = "component"
+
+	// DefaultComponent is the component that is assigned to log records
+	// that do not specify one.
+	DefaultComponent = "gocrane"
+)
+
+// Format specifies the encoding of log records.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat converts the specified string to a Format.
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(value); format {
+	case FormatText, FormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown log format %q", value)
+	}
+}
+
+// NewHandler creates a slog.Handler that writes records of the specified
+// minimum level to out, using the specified format.
+//
+// Records that are not attributed to a component through the ComponentKey
+// attribute are attributed to the DefaultComponent.
+func NewHandler(out io.Writer, format Format, level slog.Leveler) slog.Handler {
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(out, &slog.HandlerOptions{
+			Level: level,
+		})
+	default:
+		handler = slog.NewTextHandler(out, &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				if len(groups) == 0 && attr.Key == slog.TimeKey {
+					attr.Value = slog.StringValue(attr.Value.Time().Format(time.TimeOnly))
+				}
+				return attr
+			},
+		})
+	}
+	return &componentHandler{
+		Handler: handler,
+	}
+}
+
+type componentHandler struct {
+	slog.Handler
+	hasComponent bool
+}
+
+func (h *componentHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.hasComponent {
+		record = record.Clone()
+		record.AddAttrs(slog.String(ComponentKey, DefaultComponent))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hasComponent := h.hasComponent
+	for _, attr := range attrs {
+		if attr.Key == ComponentKey {
+			hasComponent = true
+		}
+	}
+	return &componentHandler{
+		Handler:      h.Handler.WithAttrs(attrs),
+		hasComponent: hasComponent,
+	}
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return &componentHandler{
+		Handler:      h.Handler.WithGroup(name),
+		hasComponent: h.hasComponent,
+	}
+}
@@ -0,0 +1,53 @@
+package logutil_test
+
+import (
+	"log/slog"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/mokiat/gocrane/internal/logutil"
+)
+
+var _ = Describe("Handler", func() {
+	var buffer *gbytes.Buffer
+
+	BeforeEach(func() {
+		buffer = gbytes.NewBuffer()
+	})
+
+	Describe("ParseFormat", func() {
+		It("accepts known formats", func() {
+			Expect(logutil.ParseFormat("text")).To(Equal(logutil.FormatText))
+			Expect(logutil.ParseFormat("json")).To(Equal(logutil.FormatJSON))
+		})
+
+		It("rejects unknown formats", func() {
+			_, err := logutil.ParseFormat("xml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("NewHandler", func() {
+		It("attributes records to the default component", func() {
+			logger := slog.New(logutil.NewHandler(buffer, logutil.FormatJSON, slog.LevelInfo))
+			logger.Info("hello", "path", "/tmp")
+			Expect(buffer).To(gbytes.Say(`"msg":"hello","path":"/tmp","component":"gocrane"`))
+		})
+
+		It("keeps explicitly specified components", func() {
+			logger := slog.New(logutil.NewHandler(buffer, logutil.FormatJSON, slog.LevelInfo))
+			logger.With(logutil.ComponentKey, "program").Info("hello")
+			Expect(buffer).To(gbytes.Say(`"msg":"hello","component":"program"}`))
+		})
+
+		It("omits records below the configured level", func() {
+			logger := slog.New(logutil.NewHandler(buffer, logutil.FormatText, slog.LevelInfo))
+			logger.Debug("first")
+			logger.Info("second")
+			Expect(buffer).ToNot(gbytes.Say("first"))
+			Expect(buffer).To(gbytes.Say(`level=INFO msg=second component=gocrane`))
+		})
+	})
+})
@@ -0,0 +1,71 @@
+package logutil
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// NewPrefixWriter creates a new PrefixWriter that writes to out, preceding
+// each line with the specified prefix.
+func NewPrefixWriter(out io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{
+		out:    out,
+		prefix: []byte(prefix),
+	}
+}
+
+// PrefixWriter is an io.Writer that precedes each line with a prefix.
+//
+// Partial lines are buffered until they are completed by a subsequent write
+// or until Flush is called, so that a line that is written in multiple
+// chunks still receives a single prefix.
+type PrefixWriter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	prefix  []byte
+	partial []byte
+}
+
+// Write writes the complete lines of data to the underlying io.Writer and
+// buffers any trailing partial line.
+func (w *PrefixWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	count := len(data)
+	for len(data) > 0 {
+		index := bytes.IndexByte(data, '\n')
+		if index < 0 {
+			w.partial = append(w.partial, data...)
+			break
+		}
+		if err := w.writeLine(data[:index+1]); err != nil {
+			return count - len(data), err
+		}
+		data = data[index+1:]
+	}
+	return count, nil
+}
+
+// Flush writes any buffered partial line to the underlying io.Writer,
+// terminating it with a new line.
+func (w *PrefixWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) == 0 {
+		return nil
+	}
+	return w.writeLine([]byte{'\n'})
+}
+
+func (w *PrefixWriter) writeLine(ending []byte) error {
+	line := make([]byte, 0, len(w.prefix)+len(w.partial)+len(ending))
+	line = append(line, w.prefix...)
+	line = append(line, w.partial...)
+	line = append(line, ending...)
+	w.partial = w.partial[:0]
+	_, err := w.out.Write(line)
+	return err
+}
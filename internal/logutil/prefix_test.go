@@ -0,0 +1,53 @@
+package logutil_test
+
+import (
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/mokiat/gocrane/internal/logutil"
+)
+
+var _ = Describe("PrefixWriter", func() {
+	var (
+		buffer *gbytes.Buffer
+		writer *logutil.PrefixWriter
+	)
+
+	BeforeEach(func() {
+		buffer = gbytes.NewBuffer()
+		writer = logutil.NewPrefixWriter(buffer, "[test]: ")
+	})
+
+	It("prefixes each complete line", func() {
+		io.WriteString(writer, "first line\nsecond line\n")
+		Expect(buffer.Contents()).To(Equal([]byte("[test]: first line\n[test]: second line\n")))
+	})
+
+	It("buffers partial lines across writes", func() {
+		io.WriteString(writer, "first ")
+		Expect(buffer.Contents()).To(BeEmpty())
+
+		io.WriteString(writer, "line\nsecond")
+		Expect(buffer.Contents()).To(Equal([]byte("[test]: first line\n")))
+
+		io.WriteString(writer, " line\n")
+		Expect(buffer.Contents()).To(Equal([]byte("[test]: first line\n[test]: second line\n")))
+	})
+
+	It("preserves empty lines", func() {
+		io.WriteString(writer, "\n")
+		Expect(buffer.Contents()).To(Equal([]byte("[test]: \n")))
+	})
+
+	It("writes out partial lines when flushed", func() {
+		io.WriteString(writer, "partial")
+		Expect(writer.Flush()).To(Succeed())
+		Expect(buffer.Contents()).To(Equal([]byte("[test]: partial\n")))
+
+		Expect(writer.Flush()).To(Succeed())
+		Expect(buffer.Contents()).To(Equal([]byte("[test]: partial\n")))
+	})
+})
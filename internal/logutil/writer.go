@@ -2,26 +2,29 @@ package logutil
 
 import (
 	"io"
-	"log"
+	"log/slog"
 	"strings"
 )
 
-// ToWriter converts a *log.Logger to an io.Writer
-func ToWriter(logger *log.Logger) io.Writer {
+// ToWriter converts a *slog.Logger to an io.Writer. Each written line is
+// logged as a separate record.
+func ToWriter(logger *slog.Logger) io.Writer {
 	return &writerLogger{
 		logger: logger,
 	}
 }
 
 type writerLogger struct {
-	logger *log.Logger
+	logger *slog.Logger
 }
 
 func (l writerLogger) Write(data []byte) (int, error) {
 	lines := strings.Split(string(data), "\n")
 	for _, line := range lines {
+		// Terminals use carriage returns as part of line endings.
+		line = strings.TrimSuffix(line, "\r")
 		if line != "" {
-			l.logger.Println(line)
+			l.logger.Info(line)
 		}
 	}
 	return len(data), nil
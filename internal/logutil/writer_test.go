@@ -2,7 +2,7 @@ package logutil_test
 
 import (
 	"io"
-	"log"
+	"log/slog"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -19,19 +19,19 @@ var _ = Describe("Writer", func() {
 
 	BeforeEach(func() {
 		buffer = gbytes.NewBuffer()
-		logger := log.New(buffer, "test: ", log.Ldate)
+		logger := slog.New(slog.NewTextHandler(buffer, nil))
 		writer = logutil.ToWriter(logger)
 	})
 
 	It("writes out a partial line as a single line", func() {
 		io.WriteString(writer, "first line")
-		Expect(buffer).To(gbytes.Say("first line\n"))
+		Expect(buffer).To(gbytes.Say(`msg="first line"\n`))
 	})
 
 	It("writes out two lines as two lines", func() {
 		io.WriteString(writer, "first line\n")
 		io.WriteString(writer, "second line\n")
-		Expect(buffer).To(gbytes.Say("first line\n"))
-		Expect(buffer).To(gbytes.Say("second line\n"))
+		Expect(buffer).To(gbytes.Say(`msg="first line"\n`))
+		Expect(buffer).To(gbytes.Say(`msg="second line"\n`))
 	})
 })
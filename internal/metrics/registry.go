@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Default is the registry that holds the metrics of the application.
+var Default = NewRegistry()
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Registry holds a set of metrics and can write them in the Prometheus
+// text exposition format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// NewCounter creates a new Counter and registers it.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	counter := &Counter{
+		desc: desc{name: name, help: help},
+	}
+	r.register(counter)
+	return counter
+}
+
+// NewCounterVec creates a new CounterVec that partitions its counters by
+// the specified label and registers it.
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	counterVec := &CounterVec{
+		desc:     desc{name: name, help: help},
+		label:    label,
+		counters: make(map[string]*Counter),
+	}
+	r.register(counterVec)
+	return counterVec
+}
+
+// NewHistogram creates a new Histogram with the specified upper bucket
+// bounds, which need to be sorted in increasing order, and registers it.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	histogram := &Histogram{
+		desc:    desc{name: name, help: help},
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+	r.register(histogram)
+	return histogram
+}
+
+// WriteTo writes all registered metrics to the specified writer in the
+// order in which they were registered.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counter := &countingWriter{out: w}
+	buffer := bufio.NewWriter(counter)
+	for _, m := range r.metrics {
+		m.write(buffer)
+	}
+	err := buffer.Flush()
+	return counter.count, err
+}
+
+// Handler returns an http.Handler that serves the registered metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+type countingWriter struct {
+	out   io.Writer
+	count int64
+}
+
+func (w *countingWriter) Write(data []byte) (int, error) {
+	n, err := w.out.Write(data)
+	w.count += int64(n)
+	return n, err
+}
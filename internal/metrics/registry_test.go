@@ -0,0 +1,133 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+
+	"github.com/mokiat/gocrane/internal/metrics"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *metrics.Registry
+
+	BeforeEach(func() {
+		registry = metrics.NewRegistry()
+	})
+
+	output := func() string {
+		var builder strings.Builder
+		_, err := registry.WriteTo(&builder)
+		Expect(err).ToNot(HaveOccurred())
+		return builder.String()
+	}
+
+	It("writes counters", func() {
+		counter := registry.NewCounter("test_total", "Number of tests.")
+		counter.Inc()
+		counter.Inc()
+		Expect(output()).To(Equal(
+			"# HELP test_total Number of tests.\n" +
+				"# TYPE test_total counter\n" +
+				"test_total 2\n",
+		))
+	})
+
+	It("writes counter vectors sorted by label value", func() {
+		counterVec := registry.NewCounterVec("exits_total", "Number of exits.", "code")
+		counterVec.With("1").Inc()
+		counterVec.With("0").Inc()
+		counterVec.With("1").Inc()
+		counterVec.With(`a"b`).Inc()
+		Expect(output()).To(Equal(
+			"# HELP exits_total Number of exits.\n" +
+				"# TYPE exits_total counter\n" +
+				"exits_total{code=\"0\"} 1\n" +
+				"exits_total{code=\"1\"} 2\n" +
+				"exits_total{code=\"a\\\"b\"} 1\n",
+		))
+	})
+
+	It("writes histograms with cumulative buckets", func() {
+		histogram := registry.NewHistogram("duration_seconds", "Duration.", []float64{0.5, 1})
+		histogram.Observe(0.25)
+		histogram.Observe(0.75)
+		histogram.Observe(2)
+		Expect(output()).To(Equal(
+			"# HELP duration_seconds Duration.\n" +
+				"# TYPE duration_seconds histogram\n" +
+				"duration_seconds_bucket{le=\"0.5\"} 1\n" +
+				"duration_seconds_bucket{le=\"1\"} 2\n" +
+				"duration_seconds_bucket{le=\"+Inf\"} 3\n" +
+				"duration_seconds_sum 3\n" +
+				"duration_seconds_count 3\n",
+		))
+	})
+
+	It("writes output that can be parsed by Prometheus", func() {
+		registry.NewCounter("test_total", "Number of \\ tests.\nMultiline.").Inc()
+		counterVec := registry.NewCounterVec("exits_total", "Number of exits.", "code")
+		counterVec.With("0").Inc()
+		counterVec.With("a\"b\\c\nd").Inc()
+		histogram := registry.NewHistogram("duration_seconds", "Duration.", []float64{0.5, 1})
+		histogram.Observe(0.25)
+		histogram.Observe(2)
+
+		parser := expfmt.NewTextParser(model.LegacyValidation)
+		families, err := parser.TextToMetricFamilies(strings.NewReader(output()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(families).To(HaveLen(3))
+
+		counter := families["test_total"]
+		Expect(counter).ToNot(BeNil())
+		Expect(counter.GetType()).To(Equal(dto.MetricType_COUNTER))
+		Expect(counter.GetHelp()).To(Equal("Number of \\ tests.\nMultiline."))
+		Expect(counter.GetMetric()).To(HaveLen(1))
+		Expect(counter.GetMetric()[0].GetCounter().GetValue()).To(Equal(1.0))
+
+		exits := families["exits_total"]
+		Expect(exits).ToNot(BeNil())
+		Expect(exits.GetType()).To(Equal(dto.MetricType_COUNTER))
+		var codes []string
+		for _, m := range exits.GetMetric() {
+			Expect(m.GetLabel()).To(HaveLen(1))
+			Expect(m.GetLabel()[0].GetName()).To(Equal("code"))
+			codes = append(codes, m.GetLabel()[0].GetValue())
+		}
+		Expect(codes).To(ConsistOf("0", "a\"b\\c\nd"))
+
+		durations := families["duration_seconds"]
+		Expect(durations).ToNot(BeNil())
+		Expect(durations.GetType()).To(Equal(dto.MetricType_HISTOGRAM))
+		Expect(durations.GetMetric()).To(HaveLen(1))
+		histogramValue := durations.GetMetric()[0].GetHistogram()
+		Expect(histogramValue.GetSampleCount()).To(Equal(uint64(2)))
+		Expect(histogramValue.GetSampleSum()).To(Equal(2.25))
+		var cumulativeCounts []uint64
+		for _, bucket := range histogramValue.GetBucket() {
+			cumulativeCounts = append(cumulativeCounts, bucket.GetCumulativeCount())
+		}
+		Expect(cumulativeCounts).To(Equal([]uint64{1, 1, 2}))
+	})
+
+	It("writes metrics in registration order", func() {
+		registry.NewCounter("b_total", "B.")
+		registry.NewCounter("a_total", "A.")
+		Expect(output()).To(MatchRegexp(`(?s)b_total.*a_total`))
+	})
+
+	It("serves metrics over HTTP", func() {
+		registry.NewCounter("test_total", "Number of tests.").Inc()
+		recorder := httptest.NewRecorder()
+		registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("text/plain"))
+		Expect(recorder.Body.String()).To(ContainSubstring("test_total 1\n"))
+	})
+})
@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+// AnalysisMode determines what happens when the analysis of a new build
+// reports problems.
+type AnalysisMode string
+
+const (
+	// AnalysisModeWarn reports the problems and restarts the program anyway.
+	AnalysisModeWarn AnalysisMode = "warn"
+
+	// AnalysisModeBlock reports the problems and keeps the previous program
+	// running.
+	AnalysisModeBlock AnalysisMode = "block"
+)
+
+func Analyze(
+	ctx context.Context,
+	analyzer *project.Analyzer,
+	mode AnalysisMode,
+	in Queue[BuildEvent],
+	out Queue[BuildEvent],
+	status *Status,
+) func() error {
+
+	logger := slog.Default().With("stage", "analyze")
+
+	return func() error {
+		var (
+			accepted      BuildEvent
+			blockedBinary string
+
+			// pendingPaths holds the changed paths of all builds since the
+			// last accepted one, since a build that was blocked still
+			// contains their problems.
+			pendingPaths []string
+		)
+
+		var buildEvent BuildEvent
+		for in.Pop(ctx, &buildEvent) {
+			// A restart reuses the last built binary, which should not be
+			// started if it was blocked.
+			if buildEvent.Reused {
+				if buildEvent.Path == blockedBinary {
+					if accepted.Path == "" {
+						logger.Warn("Ignoring restart, as the last build was blocked by analysis.")
+						continue
+					}
+					logger.Info("Restarting previous binary, as the last build was blocked by analysis.")
+					buildEvent.Path = accepted.Path
+					buildEvent.BuildID = accepted.BuildID
+				}
+				accepted = buildEvent
+				out.Push(ctx, buildEvent)
+				continue
+			}
+
+			pendingPaths = append(pendingPaths, buildEvent.ChangedPaths...)
+			packageDirs := project.AffectedPackageDirs(pendingPaths)
+			if len(packageDirs) > 0 {
+				logger.Info("Analyzing...", "packages", len(packageDirs))
+				err := analyzer.Analyze(ctx, packageDirs)
+				status.setLastAnalysisError(err)
+
+				var analysisErr *project.AnalysisError
+				switch {
+				case err == nil:
+					logger.Info("Analysis was successful.")
+				case errors.As(err, &analysisErr) && mode == AnalysisModeBlock:
+					logger.Error("Analysis reported problems, keeping the previous process running.", "problems", len(analysisErr.Diagnostics))
+					blockedBinary = buildEvent.Path
+					continue
+				case errors.As(err, &analysisErr):
+					logger.Warn("Analysis reported problems.", "problems", len(analysisErr.Diagnostics))
+				default:
+					logger.Error("Analysis failure.", "error", err)
+				}
+			}
+
+			accepted = buildEvent
+			blockedBinary = ""
+			pendingPaths = nil
+			out.Push(ctx, buildEvent)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,167 @@
+package pipeline_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/pipeline"
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("Analyze", func() {
+	const (
+		passingScript = "exit 0"
+		failingScript = `echo "# example"; echo "$1/main.go:3:1: unreachable code"; exit 1`
+	)
+
+	var (
+		ctx       context.Context
+		ctxCancel func()
+		sourceDir string
+		in        pipeline.Queue[pipeline.BuildEvent]
+		out       pipeline.Queue[pipeline.BuildEvent]
+	)
+
+	startStage := func(script string, mode pipeline.AnalysisMode) {
+		analyzer := project.NewAnalyzer(sourceDir, []string{"sh", "-c", script, "sh"}, project.NewEnvironment(nil, nil))
+		go pipeline.Analyze(ctx, analyzer, mode, in, out, pipeline.NewStatus())()
+	}
+
+	buildEvent := func(binary string) pipeline.BuildEvent {
+		return pipeline.BuildEvent{
+			Path:         binary,
+			ChangedPaths: []string{filepath.Join(sourceDir, "main.go")},
+		}
+	}
+
+	restartEvent := func(binary string) pipeline.BuildEvent {
+		return pipeline.BuildEvent{
+			Path:   binary,
+			Reused: true,
+		}
+	}
+
+	BeforeEach(func() {
+		ctx, ctxCancel = context.WithCancel(context.Background())
+		sourceDir = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(sourceDir, "main.go"), []byte("package main\n"), 0o644)).To(Succeed())
+		in = make(pipeline.Queue[pipeline.BuildEvent])
+		out = make(pipeline.Queue[pipeline.BuildEvent], 1)
+	})
+
+	AfterEach(func() {
+		ctxCancel()
+	})
+
+	It("forwards builds that pass analysis", func() {
+		startStage(passingScript, pipeline.AnalysisModeBlock)
+		in <- buildEvent("/bin/first")
+		Eventually(out).Should(Receive(Equal(buildEvent("/bin/first"))))
+	})
+
+	It("forwards builds with problems in warn mode", func() {
+		startStage(failingScript, pipeline.AnalysisModeWarn)
+		in <- buildEvent("/bin/first")
+		Eventually(out).Should(Receive(Equal(buildEvent("/bin/first"))))
+	})
+
+	It("forwards builds without changed Go files", func() {
+		startStage(failingScript, pipeline.AnalysisModeBlock)
+		event := pipeline.BuildEvent{
+			Path:         "/bin/first",
+			ChangedPaths: []string{filepath.Join(sourceDir, "README.md")},
+		}
+		in <- event
+		Eventually(out).Should(Receive(Equal(event)))
+	})
+
+	When("a build is blocked", func() {
+		BeforeEach(func() {
+			startStage(failingScript, pipeline.AnalysisModeBlock)
+		})
+
+		It("does not forward it", func() {
+			in <- buildEvent("/bin/first")
+			Consistently(out).ShouldNot(Receive())
+		})
+
+		It("ignores restarts of the blocked binary", func() {
+			in <- buildEvent("/bin/first")
+			in <- restartEvent("/bin/first")
+			Consistently(out).ShouldNot(Receive())
+		})
+
+		It("restarts the previously accepted binary", func() {
+			in <- restartEvent("/bin/bootstrap")
+			Eventually(out).Should(Receive(Equal(restartEvent("/bin/bootstrap"))))
+
+			in <- pipeline.BuildEvent{
+				Path: "/bin/second",
+			}
+			Eventually(out).Should(Receive())
+
+			in <- buildEvent("/bin/third")
+			in <- restartEvent("/bin/third")
+			Eventually(out).Should(Receive(Equal(restartEvent("/bin/second"))))
+		})
+	})
+	When("problems remain in packages of blocked builds", func() {
+		// The script reports a problem for each package that contains a
+		// problem marker, so that problems can be fixed between builds.
+		const markerScript = `status=0; for dir in "$@"; do if [ -e "$dir/problem" ]; then echo "$dir/main.go:3:1: unreachable code"; status=1; fi; done; exit $status`
+
+		var (
+			firstFile  string
+			secondFile string
+			marker     string
+		)
+
+		changeEvent := func(binary, path string) pipeline.BuildEvent {
+			return pipeline.BuildEvent{
+				Path:         binary,
+				ChangedPaths: []string{path},
+			}
+		}
+
+		BeforeEach(func() {
+			firstFile = filepath.Join(sourceDir, "first", "main.go")
+			secondFile = filepath.Join(sourceDir, "second", "main.go")
+			marker = filepath.Join(sourceDir, "first", "problem")
+			for _, file := range []string{firstFile, secondFile} {
+				Expect(os.MkdirAll(filepath.Dir(file), 0o755)).To(Succeed())
+				Expect(os.WriteFile(file, []byte("package main\n"), 0o644)).To(Succeed())
+			}
+			Expect(os.WriteFile(marker, nil, 0o644)).To(Succeed())
+			startStage(markerScript, pipeline.AnalysisModeBlock)
+
+			in <- changeEvent("/bin/first", firstFile)
+			Consistently(out).ShouldNot(Receive())
+		})
+
+		It("keeps blocking builds that change other packages", func() {
+			in <- changeEvent("/bin/second", secondFile)
+			Consistently(out).ShouldNot(Receive())
+		})
+
+		It("keeps blocking builds that change no Go files", func() {
+			in <- changeEvent("/bin/second", filepath.Join(sourceDir, "README.md"))
+			Consistently(out).ShouldNot(Receive())
+		})
+
+		It("forwards the build once the problems are fixed", func() {
+			Expect(os.Remove(marker)).To(Succeed())
+			in <- changeEvent("/bin/second", secondFile)
+			Eventually(out).Should(Receive(Equal(changeEvent("/bin/second", secondFile))))
+		})
+	})
+
+	It("forwards builds when the analysis fails without reporting problems", func() {
+		startStage(`echo "go: cannot load packages"; exit 1`, pipeline.AnalysisModeBlock)
+		in <- buildEvent("/bin/first")
+		Eventually(out).Should(Receive(Equal(buildEvent("/bin/first"))))
+	})
+})
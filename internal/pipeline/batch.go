@@ -39,17 +39,23 @@ func Batch(
 			// either because flushing is disabled or the receiver is blocked, we will
 			// continue to accumulate batched events.
 			case flushChan <- batchEvent:
-				flushChan = nil        // Disable flushing.
-				batchEvent.Paths = nil // Don't reuse the slice!
+				batchesFlushedMetric.Inc()
+				flushChan = nil            // Disable flushing.
+				batchEvent = ChangeEvent{} // Don't reuse the slice!
 
 			// A sufficient amount of time has passed since the first event was received
 			// so we can enable flushing.
 			case <-flushTimer.C:
-				flushChan = out // Allow flushing.
+				if !batchEvent.IsEmpty() {
+					flushChan = out // Allow flushing.
+				}
 
 			// Try to read new events and accumulate them.
 			case event := <-in:
-				batchEvent.Paths = append(batchEvent.Paths, event.Paths...)
+				batchEvent = batchEvent.Merge(event)
+				if batchEvent.IsEmpty() {
+					flushChan = nil // Changes cancelled each other out.
+				}
 				stopTimer()
 				flushTimer.Reset(batchDuration)
 			}
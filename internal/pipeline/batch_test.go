@@ -31,18 +31,25 @@ var _ = Describe("Batch", func() {
 		ctxCancel()
 	})
 
+	newEvent := func(path string, op pipeline.Op) pipeline.ChangeEvent {
+		return pipeline.ChangeEvent{
+			Changes: []pipeline.Change{{Path: path, Op: op}},
+			Reasons: pipeline.ReasonWatch,
+		}
+	}
+
 	When("multiple events are pushed in a quick succession", func() {
 		BeforeEach(func() {
-			Expect(in.Push(ctx, pipeline.ChangeEvent{Paths: []string{"first"}})).To(BeTrue())
-			Expect(in.Push(ctx, pipeline.ChangeEvent{Paths: []string{"second"}})).To(BeTrue())
-			Expect(in.Push(ctx, pipeline.ChangeEvent{Paths: []string{"third"}})).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("first", pipeline.OpModify))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("second", pipeline.OpModify))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("third", pipeline.OpModify))).To(BeTrue())
 		})
 
 		It("produces a combined output event", func() {
 			var changeEvent pipeline.ChangeEvent
 
 			Eventually(out).Should(Receive(&changeEvent))
-			Expect(changeEvent.Paths).To(Equal([]string{
+			Expect(changeEvent.Paths()).To(Equal([]string{
 				"first", "second", "third",
 			}))
 
@@ -52,23 +59,23 @@ var _ = Describe("Batch", func() {
 
 	When("events are spread out in time", func() {
 		BeforeEach(func() {
-			Expect(in.Push(ctx, pipeline.ChangeEvent{Paths: []string{"first"}})).To(BeTrue())
-			Expect(in.Push(ctx, pipeline.ChangeEvent{Paths: []string{"second"}})).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("first", pipeline.OpModify))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("second", pipeline.OpModify))).To(BeTrue())
 			time.Sleep(500 * time.Millisecond)
-			Expect(in.Push(ctx, pipeline.ChangeEvent{Paths: []string{"third"}})).To(BeTrue())
-			Expect(in.Push(ctx, pipeline.ChangeEvent{Paths: []string{"fourth"}})).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("third", pipeline.OpModify))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("fourth", pipeline.OpModify))).To(BeTrue())
 		})
 
 		It("produces multiple output event", func() {
 			var changeEvent pipeline.ChangeEvent
 
 			Eventually(out).Should(Receive(&changeEvent))
-			Expect(changeEvent.Paths).To(Equal([]string{
+			Expect(changeEvent.Paths()).To(Equal([]string{
 				"first", "second",
 			}))
 
 			Eventually(out).Should(Receive(&changeEvent))
-			Expect(changeEvent.Paths).To(Equal([]string{
+			Expect(changeEvent.Paths()).To(Equal([]string{
 				"third", "fourth",
 			}))
 
@@ -76,10 +83,57 @@ var _ = Describe("Batch", func() {
 		})
 	})
 
+	When("changes to the same path are pushed", func() {
+		BeforeEach(func() {
+			Expect(in.Push(ctx, newEvent("first", pipeline.OpCreate))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("second", pipeline.OpRemove))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("first", pipeline.OpModify))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("second", pipeline.OpCreate))).To(BeTrue())
+			Expect(in.Push(ctx, pipeline.ChangeEvent{Reasons: pipeline.ReasonRebuildRequest})).To(BeTrue())
+		})
+
+		It("merges their operations and reasons", func() {
+			var changeEvent pipeline.ChangeEvent
+
+			Eventually(out).Should(Receive(&changeEvent))
+			Expect(changeEvent).To(Equal(pipeline.ChangeEvent{
+				Changes: []pipeline.Change{
+					{Path: "first", Op: pipeline.OpCreate},
+					{Path: "second", Op: pipeline.OpModify},
+				},
+				Reasons: pipeline.ReasonWatch | pipeline.ReasonRebuildRequest,
+			}))
+
+			Consistently(out).ShouldNot(Receive(&changeEvent))
+		})
+	})
+
+	When("changes cancel each other out", func() {
+		BeforeEach(func() {
+			Expect(in.Push(ctx, newEvent("first", pipeline.OpCreate))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("first", pipeline.OpModify))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("first", pipeline.OpRemove))).To(BeTrue())
+		})
+
+		It("does not produce an event", func() {
+			Consistently(out).ShouldNot(Receive())
+		})
+
+		It("produces an event for later changes", func() {
+			Expect(in.Push(ctx, newEvent("second", pipeline.OpModify))).To(BeTrue())
+
+			var changeEvent pipeline.ChangeEvent
+			Eventually(out).Should(Receive(&changeEvent))
+			Expect(changeEvent.Changes).To(Equal([]pipeline.Change{
+				{Path: "second", Op: pipeline.OpModify},
+			}))
+		})
+	})
+
 	When("the pipeline is cancelled", func() {
 		BeforeEach(func() {
-			Expect(in.Push(ctx, pipeline.ChangeEvent{Paths: []string{"first"}})).To(BeTrue())
-			Expect(in.Push(ctx, pipeline.ChangeEvent{Paths: []string{"second"}})).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("first", pipeline.OpModify))).To(BeTrue())
+			Expect(in.Push(ctx, newEvent("second", pipeline.OpModify))).To(BeTrue())
 			ctxCancel()
 		})
 
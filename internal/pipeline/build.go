@@ -3,9 +3,10 @@ package pipeline
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -13,16 +14,15 @@ import (
 	"github.com/mokiat/gocrane/internal/project"
 )
 
-const ForceBuildPath = "/ffb5c0d8-e6ac-4965-9080-7168f473db57"
-
 func Build(
 	ctx context.Context,
-	mainDir string,
-	buildArgs []string,
+	builder *project.Builder,
 	in Queue[ChangeEvent],
 	out Queue[BuildEvent],
 	rebuildFilter *filesystem.FilterTree,
 	restartFilter *filesystem.FilterTree,
+	discoveredResources *DiscoveredResources,
+	status *Status,
 	bootstrapEvent *BuildEvent,
 ) func() error {
 
@@ -37,10 +37,13 @@ func Build(
 		os.RemoveAll(tempDir)
 	}()
 
-	builder := project.NewBuilder(mainDir, buildArgs)
+	logger := slog.Default().With("stage", "build")
 
 	return func() error {
-		var lastBinary string
+		var (
+			lastBinary  string
+			lastBuildID string
+		)
 		if bootstrapEvent != nil {
 			lastBinary = bootstrapEvent.Path
 			out.Push(ctx, *bootstrapEvent)
@@ -48,8 +51,20 @@ func Build(
 
 		var changeEvent ChangeEvent
 		for in.Pop(ctx, &changeEvent) {
-			shouldBuild := isAnyAccepted(rebuildFilter, changeEvent.Paths) || isAnyForceRebuild(changeEvent.Paths)
-			shouldRestart := isAnyAccepted(restartFilter, changeEvent.Paths)
+			// A binary that has been removed cannot be restarted, so the next
+			// restart needs to build a new one.
+			if lastBinary != "" && isRemoved(changeEvent.Changes, lastBinary) {
+				logger.Warn("Binary was removed, a restart will rebuild it.", "path", lastBinary)
+				lastBinary = ""
+				lastBuildID = ""
+			}
+
+			paths := changeEvent.Paths()
+			shouldBuild := changeEvent.Reasons.Has(ReasonBootstrap|ReasonDigestMismatch|ReasonRebuildRequest) ||
+				isAnyAccepted(rebuildFilter, paths)
+			shouldRestart := changeEvent.Reasons.Has(ReasonRestartRequest) ||
+				isAnyAccepted(restartFilter, paths) ||
+				discoveredResources.ContainsAny(paths)
 
 			// Skip this change event. The changed files are not of relevance.
 			if !shouldBuild && !shouldRestart {
@@ -66,22 +81,37 @@ func Build(
 			// based on the last binary.
 			if !shouldBuild && shouldRestart {
 				out.Push(ctx, BuildEvent{
-					Path: lastBinary,
+					Path:    lastBinary,
+					BuildID: lastBuildID,
+					Reused:  true,
 				})
 				continue
 			}
 
-			log.Printf("Building...")
-			path := filepath.Join(tempDir, fmt.Sprintf("executable-%s", uuid.NewString()))
-			if err := builder.Build(ctx, path); err != nil {
-				log.Printf("Build failure: %s", err)
+			buildID := uuid.NewString()
+			logger.Info("Building...", "build_id", buildID, "reasons", changeEvent.Reasons, "changes", len(changeEvent.Changes))
+			status.setBuilding()
+			buildsStartedMetric.Inc()
+			startTime := time.Now()
+			path := filepath.Join(tempDir, fmt.Sprintf("executable-%s", buildID))
+			err := builder.Build(ctx, path)
+			duration := time.Since(startTime)
+			status.setBuildResult(duration, err)
+			buildDurationMetric.Observe(duration.Seconds())
+			if err != nil {
+				buildsFailedMetric.Inc()
+				logger.Error("Build failure.", "build_id", buildID, "duration", duration, "error", err)
 				continue
 			}
 
-			log.Printf("Build was successful.")
+			buildsSucceededMetric.Inc()
+			logger.Info("Build was successful.", "build_id", buildID, "duration", duration)
 			lastBinary = path
+			lastBuildID = buildID
 			out.Push(ctx, BuildEvent{
-				Path: path,
+				Path:         path,
+				BuildID:      buildID,
+				ChangedPaths: paths,
 			})
 		}
 
@@ -89,18 +119,24 @@ func Build(
 	}
 }
 
-func isAnyAccepted(filter *filesystem.FilterTree, paths []string) bool {
-	for _, path := range paths {
-		if filter.IsAccepted(path) {
-			return true
+// isRemoved returns whether the specified path is removed or renamed by
+// the changes.
+func isRemoved(changes []Change, path string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, change := range changes {
+		if change.Path == absPath {
+			return change.Op.isRemove()
 		}
 	}
 	return false
 }
 
-func isAnyForceRebuild(paths []string) bool {
+func isAnyAccepted(filter *filesystem.FilterTree, paths []string) bool {
 	for _, path := range paths {
-		if path == ForceBuildPath {
+		if filter.IsAccepted(path) {
 			return true
 		}
 	}
@@ -0,0 +1,74 @@
+package pipeline_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+	"github.com/mokiat/gocrane/internal/pipeline"
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("Build", func() {
+	var (
+		ctx        context.Context
+		ctxCancel  func()
+		binaryFile string
+		in         pipeline.Queue[pipeline.ChangeEvent]
+		out        pipeline.Queue[pipeline.BuildEvent]
+	)
+
+	BeforeEach(func() {
+		ctx, ctxCancel = context.WithCancel(context.Background())
+		dir, err := filepath.EvalSymlinks(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644)).To(Succeed())
+		binaryFile = filepath.Join(dir, "app")
+		Expect(os.WriteFile(binaryFile, nil, 0o755)).To(Succeed())
+
+		in = make(pipeline.Queue[pipeline.ChangeEvent], 1)
+		out = make(pipeline.Queue[pipeline.BuildEvent], 1)
+		go pipeline.Build(ctx,
+			project.NewBuilder(dir, nil, project.NewEnvironment(nil, nil)),
+			in,
+			out,
+			filesystem.NewFilterTree(),
+			filesystem.NewFilterTree(),
+			pipeline.NewDiscoveredResources(),
+			pipeline.NewStatus(),
+			&pipeline.BuildEvent{Path: binaryFile},
+		)()
+		Eventually(out).Should(Receive())
+	})
+
+	AfterEach(func() {
+		ctxCancel()
+	})
+
+	It("restarts the existing binary", func() {
+		in <- pipeline.ChangeEvent{Reasons: pipeline.ReasonRestartRequest}
+		var event pipeline.BuildEvent
+		Eventually(out).Should(Receive(&event))
+		Expect(event.Path).To(Equal(binaryFile))
+		Expect(event.Reused).To(BeTrue())
+	})
+
+	It("rebuilds on restart when the existing binary was removed", func() {
+		in <- pipeline.ChangeEvent{
+			Changes: []pipeline.Change{{Path: binaryFile, Op: pipeline.OpRemove}},
+			Reasons: pipeline.ReasonWatch,
+		}
+		in <- pipeline.ChangeEvent{Reasons: pipeline.ReasonRestartRequest}
+		var event pipeline.BuildEvent
+		Eventually(out).WithTimeout(time.Minute).Should(Receive(&event))
+		Expect(event.Path).ToNot(Equal(binaryFile))
+		Expect(event.Reused).To(BeFalse())
+		Expect(event.Path).To(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,52 @@
+package pipeline
+
+import "context"
+
+// NewController creates a new Controller that injects requests into the
+// specified queues.
+func NewController(changes Queue[ChangeEvent], pauses Queue[PauseEvent], stops Queue[StopEvent]) *Controller {
+	return &Controller{
+		changes: changes,
+		pauses:  pauses,
+		stops:   stops,
+	}
+}
+
+// Controller can be used to make manual requests to the pipeline.
+//
+// Requests are injected into the pipeline queues as events, so that they
+// are processed in the same way as events that are produced by the
+// pipeline itself.
+type Controller struct {
+	changes Queue[ChangeEvent]
+	pauses  Queue[PauseEvent]
+	stops   Queue[StopEvent]
+}
+
+// Rebuild requests that the program be rebuilt and restarted.
+func (c *Controller) Rebuild(ctx context.Context) bool {
+	return c.changes.Push(ctx, ChangeEvent{
+		Reasons: ReasonRebuildRequest,
+	})
+}
+
+// Restart requests that the program be restarted.
+func (c *Controller) Restart(ctx context.Context) bool {
+	return c.changes.Push(ctx, ChangeEvent{
+		Reasons: ReasonRestartRequest,
+	})
+}
+
+// SetWatchPaused requests that the watch stage stops or resumes producing
+// change events.
+func (c *Controller) SetWatchPaused(ctx context.Context, paused bool) bool {
+	return c.pauses.Push(ctx, PauseEvent{
+		Paused: paused,
+	})
+}
+
+// Stop requests that the running program be stopped. The program is started
+// again on the next rebuild or restart.
+func (c *Controller) Stop(ctx context.Context) bool {
+	return c.stops.Push(ctx, StopEvent{})
+}
@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+)
+
+// discoveryInterval specifies how often the open files of the program are
+// sampled. Programs usually read configuration files in one go, so the
+// interval needs to be short for such reads to be noticed, but each sample
+// lists all descriptors of the program, which gets costly when done more
+// often.
+const discoveryInterval = 20 * time.Millisecond
+
+// DiscoveryMode determines what happens with files that are discovered to
+// be read by the program.
+type DiscoveryMode string
+
+const (
+	// DiscoveryModePropose reports the files, so that they can be added as
+	// resources manually.
+	DiscoveryModePropose DiscoveryMode = "propose"
+
+	// DiscoveryModeAuto reports the files and treats them as resources.
+	DiscoveryModeAuto DiscoveryMode = "auto"
+)
+
+// NewDiscoveredResources creates a new empty DiscoveredResources.
+func NewDiscoveredResources() *DiscoveredResources {
+	return &DiscoveredResources{
+		paths: make(map[string]struct{}),
+	}
+}
+
+// DiscoveredResources holds the files that were discovered to be read by
+// the program and that should be treated as resources. It is safe for
+// concurrent use.
+type DiscoveredResources struct {
+	mu    sync.RWMutex
+	paths map[string]struct{}
+}
+
+// Contains returns whether the specified path is a discovered resource.
+func (r *DiscoveredResources) Contains(path string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.paths[path]
+	return ok
+}
+
+// ContainsAny returns whether any of the specified paths is a discovered
+// resource.
+func (r *DiscoveredResources) ContainsAny(paths []string) bool {
+	for _, path := range paths {
+		if r.Contains(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *DiscoveredResources) add(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths[path] = struct{}{}
+}
+
+func Discover(
+	ctx context.Context,
+	in Queue[StartEvent],
+	mode DiscoveryMode,
+	duration time.Duration,
+	watchFilter *filesystem.FilterTree,
+	sourceFilter *filesystem.FilterTree,
+	resourceFilter *filesystem.FilterTree,
+	ignoreFilter *filesystem.FilterTree,
+	resources *DiscoveredResources,
+) func() error {
+
+	logger := slog.Default().With("stage", "discover")
+
+	isCandidate := func(path string) bool {
+		return watchFilter.IsAccepted(path) &&
+			!ignoreFilter.IsAccepted(path) &&
+			!sourceFilter.IsAccepted(path) &&
+			!resourceFilter.IsAccepted(path)
+	}
+
+	return func() error {
+		var (
+			pid        int
+			ticker     *time.Ticker
+			tickerChan <-chan time.Time
+			deadline   <-chan time.Time
+			discovered []string
+			reported   = make(map[string]struct{})
+		)
+
+		stopSampling := func() {
+			if ticker != nil {
+				ticker.Stop()
+			}
+			ticker, tickerChan, deadline = nil, nil, nil
+			pid = 0
+		}
+		defer stopSampling()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+
+			// A new process has been started, which replaces any process
+			// that was sampled so far.
+			case event := <-in:
+				stopSampling()
+				logger.Debug("Discovering files read by the program...", "pid", event.PID, "duration", duration)
+				pid = event.PID
+				ticker = time.NewTicker(discoveryInterval)
+				tickerChan = ticker.C
+				deadline = time.After(duration)
+
+			case <-tickerChan:
+				files, err := filesystem.OpenFiles(pid)
+				if err != nil {
+					logger.Debug("Stopped discovering files, as the program has exited.", "pid", pid, "error", err)
+					stopSampling()
+					continue
+				}
+				for _, path := range files {
+					if _, ok := reported[path]; ok || !isCandidate(path) {
+						continue
+					}
+					reported[path] = struct{}{}
+					discovered = append(discovered, path)
+				}
+
+			case <-deadline:
+				stopSampling()
+				logger.Debug("Finished discovering files read by the program.", "files", len(discovered))
+			}
+
+			// Files are reported as soon as they are found, in case the
+			// program reads some of them late.
+			for _, path := range discovered {
+				switch mode {
+				case DiscoveryModeAuto:
+					resources.add(path)
+					logger.Info("Discovered file read by the program, will restart when it changes.", "path", path)
+				default:
+					logger.Info("Discovered file read by the program, consider adding it as a resource.", "path", path)
+				}
+			}
+			discovered = nil
+		}
+	}
+}
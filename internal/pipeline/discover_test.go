@@ -0,0 +1,91 @@
+package pipeline_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+	"github.com/mokiat/gocrane/internal/pipeline"
+)
+
+var _ = Describe("Discover", func() {
+	var (
+		ctx       context.Context
+		ctxCancel func()
+		dir       string
+		in        pipeline.Queue[pipeline.StartEvent]
+		resources *pipeline.DiscoveredResources
+	)
+
+	startStage := func(mode pipeline.DiscoveryMode) {
+		watchFilter := filesystem.NewFilterTree()
+		watchFilter.AcceptPath(dir)
+		resourceFilter := filesystem.NewFilterTree()
+		resourceFilter.AcceptGlob("*.json")
+		go pipeline.Discover(ctx, in, mode, time.Second,
+			watchFilter,
+			filesystem.NewFilterTree(),
+			resourceFilter,
+			filesystem.NewFilterTree(),
+			resources,
+		)()
+	}
+
+	openFile := func(name string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, nil, 0o644)).To(Succeed())
+		file, err := os.Open(path)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(file.Close)
+		return path
+	}
+
+	BeforeEach(func() {
+		if runtime.GOOS != "linux" {
+			Skip("discovery is only available on Linux")
+		}
+		ctx, ctxCancel = context.WithCancel(context.Background())
+		var err error
+		dir, err = filepath.EvalSymlinks(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		in = make(pipeline.Queue[pipeline.StartEvent], 1)
+		resources = pipeline.NewDiscoveredResources()
+	})
+
+	AfterEach(func() {
+		ctxCancel()
+	})
+
+	It("adds files that are read by the program in auto mode", func() {
+		path := openFile("config.yaml")
+		startStage(pipeline.DiscoveryModeAuto)
+		in <- pipeline.StartEvent{PID: os.Getpid()}
+		Eventually(func() bool {
+			return resources.Contains(path)
+		}).Should(BeTrue())
+	})
+
+	It("only reports files in propose mode", func() {
+		path := openFile("config.yaml")
+		startStage(pipeline.DiscoveryModePropose)
+		in <- pipeline.StartEvent{PID: os.Getpid()}
+		Consistently(func() bool {
+			return resources.Contains(path)
+		}).Should(BeFalse())
+	})
+
+	It("ignores files that are already resources", func() {
+		path := openFile("config.json")
+		startStage(pipeline.DiscoveryModeAuto)
+		in <- pipeline.StartEvent{PID: os.Getpid()}
+		Consistently(func() bool {
+			return resources.Contains(path)
+		}).Should(BeFalse())
+	})
+})
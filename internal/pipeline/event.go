@@ -1,6 +1,10 @@
 package pipeline
 
-import "context"
+import (
+	"context"
+	"slices"
+	"strings"
+)
 
 type Queue[T any] chan T
 
@@ -26,10 +30,219 @@ func (q Queue[T]) Pop(ctx context.Context, ptr *T) bool {
 	}
 }
 
+// Op describes how a path has changed.
+type Op uint8
+
+const (
+	// OpCreate indicates that the path was created.
+	OpCreate Op = iota + 1
+
+	// OpModify indicates that the content of the path has changed,
+	// including when it was replaced by another file.
+	OpModify
+
+	// OpRemove indicates that the path was removed.
+	OpRemove
+
+	// OpRename indicates that the path was renamed. The new path, if it is
+	// watched, is reported separately as renamed to.
+	OpRename
+
+	// OpRenameTo indicates that another path was renamed to the path. The
+	// operating system does not report which path that was, so the two
+	// are not linked.
+	OpRenameTo
+)
+
+// String returns a human-readable representation of the operation.
+func (o Op) String() string {
+	switch o {
+	case OpCreate:
+		return "create"
+	case OpModify:
+		return "modify"
+	case OpRemove:
+		return "remove"
+	case OpRename:
+		return "rename"
+	case OpRenameTo:
+		return "rename_to"
+	default:
+		return "unknown"
+	}
+}
+
+// isCreate returns whether the operation makes the path appear.
+func (o Op) isCreate() bool {
+	return o == OpCreate || o == OpRenameTo
+}
+
+// isRemove returns whether the operation makes the path disappear.
+func (o Op) isRemove() bool {
+	return o == OpRemove || o == OpRename
+}
+
+// Reason describes why a change event was produced. Reasons are flags, so
+// that events that are merged together keep all of their reasons.
+type Reason uint8
+
+const (
+	// ReasonWatch indicates that watched paths have changed.
+	ReasonWatch Reason = 1 << iota
+
+	// ReasonRescan indicates that watched paths were found to have changed
+	// when rescanning them, after filesystem events were lost.
+	ReasonRescan
+
+	// ReasonBootstrap indicates that there is no binary to start with.
+	ReasonBootstrap
+
+	// ReasonDigestMismatch indicates that the existing binary is outdated.
+	ReasonDigestMismatch
+
+	// ReasonRebuildRequest indicates that a rebuild was requested manually.
+	ReasonRebuildRequest
+
+	// ReasonRestartRequest indicates that a restart was requested manually.
+	ReasonRestartRequest
+)
+
+var reasonNames = []string{
+	"watch",
+	"rescan",
+	"bootstrap",
+	"digest_mismatch",
+	"rebuild_request",
+	"restart_request",
+}
+
+// Has returns whether any of the specified reasons is set.
+func (r Reason) Has(other Reason) bool {
+	return r&other != 0
+}
+
+// String returns a human-readable representation of the reasons.
+func (r Reason) String() string {
+	var names []string
+	for i, name := range reasonNames {
+		if r.Has(1 << i) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// Change describes how a single path has changed.
+type Change struct {
+	Path string
+	Op   Op
+}
+
+// ChangeEvent describes changes that should be considered for a rebuild or
+// a restart.
 type ChangeEvent struct {
-	Paths []string
+	Changes []Change
+	Reasons Reason
+}
+
+// Paths returns the paths of all changes.
+func (e ChangeEvent) Paths() []string {
+	result := make([]string, len(e.Changes))
+	for i, change := range e.Changes {
+		result[i] = change.Path
+	}
+	return result
+}
+
+// IsEmpty returns whether the event carries nothing to act upon. An event
+// without changes is empty unless it has a reason besides the changes of
+// watched paths, such as a manual request.
+func (e ChangeEvent) IsEmpty() bool {
+	return len(e.Changes) == 0 && e.Reasons&^(ReasonWatch|ReasonRescan) == 0
+}
+
+// Merge combines the specified event with this one, as if its changes
+// happened after the changes of this one. Changes to the same path are
+// combined into a single one, where a path that is created and then
+// removed is dropped altogether.
+func (e ChangeEvent) Merge(other ChangeEvent) ChangeEvent {
+	return ChangeEvent{
+		Changes: MergeChanges(e.Changes, other.Changes),
+		Reasons: e.Reasons | other.Reasons,
+	}
+}
+
+// MergeChanges combines the specified changes, as if next happened after
+// previous. The order in which paths first changed is preserved.
+func MergeChanges(previous, next []Change) []Change {
+	if len(next) == 0 {
+		return previous
+	}
+	result := make([]Change, 0, len(previous)+len(next))
+	indices := make(map[string]int, len(previous)+len(next))
+	for _, changes := range [][]Change{previous, next} {
+		for _, change := range changes {
+			index, ok := indices[change.Path]
+			if !ok {
+				indices[change.Path] = len(result)
+				result = append(result, change)
+				continue
+			}
+			result[index].Op = mergeOp(result[index].Op, change.Op)
+		}
+	}
+	// Drop changes that cancelled each other out.
+	return slices.DeleteFunc(result, func(change Change) bool {
+		return change.Op == 0
+	})
+}
+
+// mergeOp returns the operation that has the same effect as the two
+// specified ones happening in order, or zero if they cancel each other out.
+func mergeOp(previous, next Op) Op {
+	switch {
+	case previous == 0:
+		// The path was created and removed, so it was never seen.
+		return next
+	case previous.isCreate() && next.isRemove():
+		return 0
+	case previous.isCreate():
+		return previous
+	case next.isCreate():
+		// The path existed before, so it has been replaced.
+		return OpModify
+	default:
+		return next
+	}
+}
+
+// PauseEvent requests that the watch stage stops or resumes producing
+// change events.
+type PauseEvent struct {
+	Paused bool
+}
+
+// StopEvent requests that the running program be stopped.
+type StopEvent struct{}
+
+// StartEvent reports that a new process of the program has been started.
+type StartEvent struct {
+	PID int
 }
 
 type BuildEvent struct {
 	Path string
+
+	// BuildID identifies the build that produced the binary. It is empty
+	// if the binary was not built by the pipeline.
+	BuildID string
+
+	// ChangedPaths holds the paths whose change triggered a new build. It is
+	// empty when an existing binary is reused (e.g. on restart) or when the
+	// build was not caused by changes (e.g. on a manual rebuild).
+	ChangedPaths []string
+
+	// Reused indicates that the binary was not built for this event but is
+	// an existing one that is started again (e.g. on restart).
+	Reused bool
 }
@@ -75,4 +75,81 @@ var _ = Describe("Event", func() {
 			})
 		})
 	})
+
+	Describe("MergeChanges", func() {
+		merge := func(previous, next pipeline.Op) []pipeline.Change {
+			return pipeline.MergeChanges(
+				[]pipeline.Change{{Path: "file", Op: previous}},
+				[]pipeline.Change{{Path: "file", Op: next}},
+			)
+		}
+
+		DescribeTable("combines operations on the same path",
+			func(previous, next, expected pipeline.Op) {
+				Expect(merge(previous, next)).To(Equal([]pipeline.Change{
+					{Path: "file", Op: expected},
+				}))
+			},
+			Entry("create and modify", pipeline.OpCreate, pipeline.OpModify, pipeline.OpCreate),
+			Entry("modify and modify", pipeline.OpModify, pipeline.OpModify, pipeline.OpModify),
+			Entry("modify and remove", pipeline.OpModify, pipeline.OpRemove, pipeline.OpRemove),
+			Entry("modify and rename", pipeline.OpModify, pipeline.OpRename, pipeline.OpRename),
+			Entry("remove and create", pipeline.OpRemove, pipeline.OpCreate, pipeline.OpModify),
+			Entry("rename and create", pipeline.OpRename, pipeline.OpCreate, pipeline.OpModify),
+			Entry("rename to and modify", pipeline.OpRenameTo, pipeline.OpModify, pipeline.OpRenameTo),
+			Entry("remove and rename to", pipeline.OpRemove, pipeline.OpRenameTo, pipeline.OpModify),
+		)
+
+		It("drops paths that are created and removed", func() {
+			Expect(merge(pipeline.OpCreate, pipeline.OpRemove)).To(BeEmpty())
+			Expect(merge(pipeline.OpCreate, pipeline.OpRename)).To(BeEmpty())
+			Expect(merge(pipeline.OpRenameTo, pipeline.OpRemove)).To(BeEmpty())
+		})
+
+		It("preserves the order in which paths first changed", func() {
+			Expect(pipeline.MergeChanges(
+				[]pipeline.Change{
+					{Path: "first", Op: pipeline.OpModify},
+					{Path: "second", Op: pipeline.OpCreate},
+				},
+				[]pipeline.Change{
+					{Path: "third", Op: pipeline.OpCreate},
+					{Path: "first", Op: pipeline.OpRemove},
+				},
+			)).To(Equal([]pipeline.Change{
+				{Path: "first", Op: pipeline.OpRemove},
+				{Path: "second", Op: pipeline.OpCreate},
+				{Path: "third", Op: pipeline.OpCreate},
+			}))
+		})
+	})
+
+	Describe("ChangeEvent", func() {
+		It("is empty without changes and requests", func() {
+			Expect(pipeline.ChangeEvent{}.IsEmpty()).To(BeTrue())
+			Expect(pipeline.ChangeEvent{Reasons: pipeline.ReasonWatch}.IsEmpty()).To(BeTrue())
+			Expect(pipeline.ChangeEvent{Reasons: pipeline.ReasonRescan}.IsEmpty()).To(BeTrue())
+		})
+
+		It("is not empty with changes", func() {
+			Expect(pipeline.ChangeEvent{
+				Changes: []pipeline.Change{{Path: "file", Op: pipeline.OpModify}},
+				Reasons: pipeline.ReasonWatch,
+			}.IsEmpty()).To(BeFalse())
+		})
+
+		It("is not empty with a request", func() {
+			Expect(pipeline.ChangeEvent{Reasons: pipeline.ReasonRestartRequest}.IsEmpty()).To(BeFalse())
+			Expect(pipeline.ChangeEvent{Reasons: pipeline.ReasonWatch | pipeline.ReasonRebuildRequest}.IsEmpty()).To(BeFalse())
+		})
+	})
+
+	Describe("Reason", func() {
+		It("lists all reasons", func() {
+			reasons := pipeline.ReasonWatch | pipeline.ReasonRebuildRequest
+			Expect(reasons.String()).To(Equal("watch|rebuild_request"))
+			Expect(reasons.Has(pipeline.ReasonRebuildRequest)).To(BeTrue())
+			Expect(reasons.Has(pipeline.ReasonRestartRequest)).To(BeFalse())
+		})
+	})
 })
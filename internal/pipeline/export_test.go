@@ -0,0 +1,20 @@
+package pipeline
+
+import "github.com/fsnotify/fsnotify"
+
+// InterceptWatchers makes the Watch stages that are started afterwards
+// send their filesystem watchers to the returned channel. The returned
+// function restores the default behavior.
+func InterceptWatchers() (<-chan *fsnotify.Watcher, func()) {
+	watchers := make(chan *fsnotify.Watcher, 1)
+	newFSWatcher = func() (*fsnotify.Watcher, error) {
+		watcher, err := fsnotify.NewWatcher()
+		if err == nil {
+			watchers <- watcher
+		}
+		return watcher, err
+	}
+	return watchers, func() {
+		newFSWatcher = fsnotify.NewWatcher
+	}
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+const (
+	// RestartCommand is the line of input that requests a restart of the
+	// running program.
+	RestartCommand = "rs"
+
+	// RebuildCommand is the line of input that requests a rebuild of the
+	// program.
+	RebuildCommand = "rb"
+)
+
+func Input(
+	ctx context.Context,
+	relay *project.InputRelay,
+	controller *Controller,
+) func() error {
+
+	logger := slog.Default().With("stage", "input")
+
+	return func() error {
+		lines, readErr := readInput(ctx, relay.ReadLine)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+
+			case err := <-readErr:
+				if !errors.Is(err, io.EOF) {
+					logger.Error("Failed to read input.", "error", err)
+				} else {
+					logger.Debug("Input was closed.")
+				}
+				return nil
+
+			case line := <-lines:
+				switch strings.TrimSpace(line) {
+				case RestartCommand:
+					logger.Info("Restart requested.")
+					controller.Restart(ctx)
+				case RebuildCommand:
+					logger.Info("Rebuild requested.")
+					controller.Rebuild(ctx)
+				default:
+					relay.Forward(line)
+				}
+			}
+		}
+	}
+}
+
+// readInput calls the specified read function repeatedly in the background
+// and delivers the results through the returned channels. The error channel
+// receives the first error, after which reading stops.
+func readInput[T any](ctx context.Context, read func() (T, error)) (<-chan T, <-chan error) {
+	values := make(chan T)
+	readErr := make(chan error, 1)
+
+	// Reading from the input cannot be interrupted, so this goroutine
+	// might outlive the stage.
+	go func() {
+		for {
+			value, err := read()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case values <- value:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return values, readErr
+}
@@ -0,0 +1,44 @@
+package pipeline_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/pipeline"
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("Input", func() {
+	var (
+		ctx       context.Context
+		ctxCancel func()
+		out       pipeline.Queue[pipeline.ChangeEvent]
+	)
+
+	BeforeEach(func() {
+		ctx, ctxCancel = context.WithCancel(context.Background())
+		out = make(pipeline.Queue[pipeline.ChangeEvent], 2)
+		relay := project.NewInputRelay(strings.NewReader("hello\nrs\n  rb  \r\n"))
+		controller := pipeline.NewController(out, make(pipeline.Queue[pipeline.PauseEvent]), make(pipeline.Queue[pipeline.StopEvent]))
+		go pipeline.Input(ctx, relay, controller)()
+	})
+
+	AfterEach(func() {
+		ctxCancel()
+	})
+
+	It("produces events for input commands", func() {
+		var changeEvent pipeline.ChangeEvent
+
+		Eventually(out).Should(Receive(&changeEvent))
+		Expect(changeEvent.Reasons).To(Equal(pipeline.ReasonRestartRequest))
+
+		Eventually(out).Should(Receive(&changeEvent))
+		Expect(changeEvent.Reasons).To(Equal(pipeline.ReasonRebuildRequest))
+
+		Consistently(out).ShouldNot(Receive(&changeEvent))
+	})
+})
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+const keyboardHelp = `Keyboard controls:
+  r - rebuild and restart
+  s - restart
+  p - pause or resume watching
+  v - toggle verbose logging
+  e - show last build errors
+  c - clear screen
+  q - quit
+  h - show this help
+`
+
+const clearScreenSequence = "\x1b[H\x1b[2J"
+
+func Keyboard(
+	ctx context.Context,
+	relay *project.InputRelay,
+	out io.Writer,
+	controller *Controller,
+	status *Status,
+	logLevel *slog.LevelVar,
+	quit func(),
+) func() error {
+
+	logger := slog.Default().With("stage", "keyboard")
+
+	return func() error {
+		keys, readErr := readInput(ctx, relay.ReadKey)
+
+		fmt.Fprint(out, keyboardHelp)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+
+			case err := <-readErr:
+				if !errors.Is(err, io.EOF) {
+					logger.Error("Failed to read keyboard input.", "error", err)
+				}
+				return nil
+
+			case key := <-keys:
+				switch key {
+				case 'r':
+					logger.Info("Rebuild requested.")
+					controller.Rebuild(ctx)
+				case 's':
+					logger.Info("Restart requested.")
+					controller.Restart(ctx)
+				case 'p':
+					controller.SetWatchPaused(ctx, !status.IsWatchPaused())
+				case 'v':
+					if logLevel.Level() == slog.LevelDebug {
+						logLevel.Set(slog.LevelInfo)
+					} else {
+						logLevel.Set(slog.LevelDebug)
+					}
+					logger.Info("Changed log level.", "level", logLevel.Level())
+				case 'e':
+					printBuildError(out, status.LastBuildError())
+				case 'c':
+					fmt.Fprint(out, clearScreenSequence)
+				case 'q':
+					logger.Info("Quit requested.")
+					quit()
+					return nil
+				case 'h', '?':
+					fmt.Fprint(out, keyboardHelp)
+				}
+			}
+		}
+	}
+}
+
+func printBuildError(out io.Writer, err error) {
+	var buildErr *project.BuildError
+	switch {
+	case err == nil:
+		fmt.Fprintln(out, "The last build did not fail.")
+	case errors.As(err, &buildErr) && len(buildErr.Diagnostics) > 0:
+		fmt.Fprintln(out, "The last build failed:")
+		for _, diagnostic := range buildErr.Diagnostics {
+			fmt.Fprintln(out, diagnostic)
+		}
+	case errors.As(err, &buildErr):
+		fmt.Fprintf(out, "The last build failed:\n%s", buildErr.Output)
+	default:
+		fmt.Fprintf(out, "The last build failed: %v\n", err)
+	}
+}
@@ -0,0 +1,106 @@
+package pipeline_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/mokiat/gocrane/internal/pipeline"
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("Keyboard", func() {
+	var (
+		ctx       context.Context
+		ctxCancel func()
+		keys      *io.PipeWriter
+		output    *gbytes.Buffer
+		changes   pipeline.Queue[pipeline.ChangeEvent]
+		pauses    pipeline.Queue[pipeline.PauseEvent]
+		logLevel  *slog.LevelVar
+		quitCount int
+		done      chan error
+	)
+
+	BeforeEach(func() {
+		ctx, ctxCancel = context.WithCancel(context.Background())
+		var in *io.PipeReader
+		in, keys = io.Pipe()
+		output = gbytes.NewBuffer()
+		changes = make(pipeline.Queue[pipeline.ChangeEvent], 2)
+		pauses = make(pipeline.Queue[pipeline.PauseEvent], 2)
+		logLevel = new(slog.LevelVar)
+		quitCount = 0
+		done = make(chan error, 1)
+
+		controller := pipeline.NewController(changes, pauses, make(pipeline.Queue[pipeline.StopEvent]))
+		status := pipeline.NewStatus()
+		quit := func() {
+			quitCount++
+		}
+		go func(stage func() error, done chan<- error) {
+			done <- stage()
+		}(pipeline.Keyboard(ctx, project.NewInputRelay(in), output, controller, status, logLevel, quit), done)
+		Eventually(output).Should(gbytes.Say("Keyboard controls:"))
+	})
+
+	AfterEach(func() {
+		ctxCancel()
+		keys.Close()
+	})
+
+	It("requests a rebuild", func() {
+		io.WriteString(keys, "r")
+		var changeEvent pipeline.ChangeEvent
+		Eventually(changes).Should(Receive(&changeEvent))
+		Expect(changeEvent.Reasons).To(Equal(pipeline.ReasonRebuildRequest))
+	})
+
+	It("requests a restart", func() {
+		io.WriteString(keys, "s")
+		var changeEvent pipeline.ChangeEvent
+		Eventually(changes).Should(Receive(&changeEvent))
+		Expect(changeEvent.Reasons).To(Equal(pipeline.ReasonRestartRequest))
+	})
+
+	It("requests that watching be paused", func() {
+		io.WriteString(keys, "p")
+		var pauseEvent pipeline.PauseEvent
+		Eventually(pauses).Should(Receive(&pauseEvent))
+		Expect(pauseEvent.Paused).To(BeTrue())
+	})
+
+	It("toggles verbose logging", func() {
+		io.WriteString(keys, "v")
+		Eventually(logLevel.Level).Should(Equal(slog.LevelDebug))
+		io.WriteString(keys, "v")
+		Eventually(logLevel.Level).Should(Equal(slog.LevelInfo))
+	})
+
+	It("reports that there is no build error", func() {
+		io.WriteString(keys, "e")
+		Eventually(output).Should(gbytes.Say("The last build did not fail."))
+	})
+
+	It("ignores unknown keys", func() {
+		io.WriteString(keys, "x")
+		Consistently(changes).ShouldNot(Receive())
+		Consistently(pauses).ShouldNot(Receive())
+	})
+
+	It("quits", func() {
+		io.WriteString(keys, "q")
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(quitCount).To(Equal(1))
+	})
+
+	It("stops when the input is closed", func() {
+		keys.Close()
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(quitCount).To(BeZero())
+	})
+})
@@ -0,0 +1,53 @@
+package pipeline
+
+import "github.com/mokiat/gocrane/internal/metrics"
+
+var (
+	watchEventsMetric = metrics.Default.NewCounter(
+		"gocrane_watch_events_total",
+		"Number of filesystem events received by the watch stage.",
+	)
+	watchRescansMetric = metrics.Default.NewCounter(
+		"gocrane_watch_rescans_total",
+		"Number of rescans of the watched paths after filesystem events were lost.",
+	)
+	watchUnchangedWritesMetric = metrics.Default.NewCounter(
+		"gocrane_watch_unchanged_writes_total",
+		"Number of file writes that were ignored because they did not change the content.",
+	)
+	batchesFlushedMetric = metrics.Default.NewCounter(
+		"gocrane_batches_flushed_total",
+		"Number of batched change events flushed by the batch stage.",
+	)
+	buildsStartedMetric = metrics.Default.NewCounter(
+		"gocrane_builds_started_total",
+		"Number of started builds.",
+	)
+	buildsSucceededMetric = metrics.Default.NewCounter(
+		"gocrane_builds_succeeded_total",
+		"Number of successful builds.",
+	)
+	buildsFailedMetric = metrics.Default.NewCounter(
+		"gocrane_builds_failed_total",
+		"Number of failed builds.",
+	)
+	buildDurationMetric = metrics.Default.NewHistogram(
+		"gocrane_build_duration_seconds",
+		"Duration of builds.",
+		[]float64{0.25, 0.5, 1, 2, 4, 8, 15, 30, 60, 120},
+	)
+	restartDurationMetric = metrics.Default.NewHistogram(
+		"gocrane_restart_duration_seconds",
+		"Time taken to stop the previous program and start the new one.",
+		[]float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	)
+	processExitsMetric = metrics.Default.NewCounterVec(
+		"gocrane_process_exits_total",
+		"Number of program exits by exit code, where -1 means that the program was terminated by a signal.",
+		"code",
+	)
+	crashRestartsMetric = metrics.Default.NewCounter(
+		"gocrane_crash_restarts_total",
+		"Number of restarts of a program that had exited with a non-zero exit code on its own.",
+	)
+)
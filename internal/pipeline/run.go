@@ -3,35 +3,85 @@ package pipeline
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/mokiat/gocrane/internal/project"
 )
 
+const (
+	// BuildIDEnvVar is the environment variable through which the program
+	// receives the ID of the build that produced it.
+	BuildIDEnvVar = "GOCRANE_BUILD_ID"
+
+	// RestartCountEnvVar is the environment variable through which the
+	// program receives the number of times it has been restarted.
+	RestartCountEnvVar = "GOCRANE_RESTART_COUNT"
+)
+
 func Run(
 	ctx context.Context,
-	runArgs []string,
+	runner *project.Runner,
 	in Queue[BuildEvent],
+	stops Queue[StopEvent],
+	starts Queue[StartEvent],
+	status *Status,
 	shutdownTimeout time.Duration,
 ) func() error {
 
-	runner := project.NewRunner(runArgs)
+	logger := slog.Default().With("stage", "run")
 
 	return func() error {
-		var runningProcess *project.Process
+		var (
+			runningProcess *project.Process
+			startCount     int
+		)
 
-		startProcess := func(path string) error {
+		startProcess := func(buildEvent BuildEvent) error {
 			if runningProcess != nil {
 				return fmt.Errorf("there is already a running process")
 			}
-			log.Printf("Starting new process...")
-			process, err := runner.Run(context.Background(), path)
+			path := buildEvent.Path
+			env := []string{
+				fmt.Sprintf("%s=%d", RestartCountEnvVar, startCount),
+			}
+			if buildEvent.BuildID != "" {
+				env = append(env, fmt.Sprintf("%s=%s", BuildIDEnvVar, buildEvent.BuildID))
+			}
+			logger.Info("Starting new process...", "path", path)
+			process, err := runner.Run(context.Background(), path, env)
 			if err != nil {
 				return fmt.Errorf("failed to start process: %w", err)
 			}
-			log.Printf("Successfully started new process.")
+			processStatus := &ProcessStatus{
+				PID:       process.PID(),
+				Binary:    path,
+				StartTime: time.Now(),
+			}
+			logger.Info("Successfully started new process.", "pid", process.PID())
 			runningProcess = process
+			startCount++
+
+			// Starts are only reported if someone is listening, which must
+			// not hold back the program.
+			select {
+			case starts <- StartEvent{PID: process.PID()}:
+			default:
+			}
+
+			status.setProcess(processStatus)
+
+			// Hashing a large binary takes time, which must not delay the
+			// restart, so the hash is added to the status once available.
+			go func() {
+				binarySHA256, err := project.CalculateContentDigest(path)
+				if err != nil {
+					logger.Debug("Failed to calculate binary hash.", "path", path, "error", err)
+					return
+				}
+				status.setProcessBinarySHA256(processStatus, binarySHA256)
+			}()
 			return nil
 		}
 
@@ -39,26 +89,42 @@ func Run(
 			if runningProcess == nil {
 				return nil
 			}
-			log.Printf("Stopping running process (timeout: %s)...", shutdownTimeout)
+			pid := runningProcess.PID()
+			logger.Info("Stopping running process...", "pid", pid, "timeout", shutdownTimeout)
+			startTime := time.Now()
 			shutdownCtx, shutdownFunc := context.WithTimeout(context.Background(), shutdownTimeout)
 			defer shutdownFunc()
 			if err := runningProcess.Stop(shutdownCtx); err != nil {
 				return fmt.Errorf("failed to stop process: %w", err)
 			}
-			log.Printf("Successfully stopped running process.")
+			processExitsMetric.With(strconv.Itoa(runningProcess.ExitCode())).Inc()
+			logger.Info("Successfully stopped running process.", "pid", pid, "duration", time.Since(startTime))
 			runningProcess = nil
+			status.setProcess(nil)
 			return nil
 		}
 
-		var buildEvent BuildEvent
-		for in.Pop(ctx, &buildEvent) {
-			if err := stopProcess(); err != nil {
-				return err
-			}
-			if err := startProcess(buildEvent.Path); err != nil {
-				return err
+		for {
+			select {
+			case <-ctx.Done():
+				return stopProcess()
+			case <-stops:
+				if err := stopProcess(); err != nil {
+					return err
+				}
+			case buildEvent := <-in:
+				startTime := time.Now()
+				if runningProcess != nil && runningProcess.Exited() && runningProcess.ExitCode() != 0 {
+					crashRestartsMetric.Inc()
+				}
+				if err := stopProcess(); err != nil {
+					return err
+				}
+				if err := startProcess(buildEvent); err != nil {
+					return err
+				}
+				restartDurationMetric.Observe(time.Since(startTime).Seconds())
 			}
 		}
-		return stopProcess()
 	}
 }
@@ -0,0 +1,73 @@
+package pipeline_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/pipeline"
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("Run", func() {
+	var (
+		ctx       context.Context
+		ctxCancel func()
+		binary    string
+		in        pipeline.Queue[pipeline.BuildEvent]
+		starts    pipeline.Queue[pipeline.StartEvent]
+		status    *pipeline.Status
+		done      chan error
+	)
+
+	BeforeEach(func() {
+		ctx, ctxCancel = context.WithCancel(context.Background())
+		binary = filepath.Join(GinkgoT().TempDir(), "program")
+		Expect(os.WriteFile(binary, []byte("#!/bin/sh\nexec sleep 10\n"), 0o755)).To(Succeed())
+		in = make(pipeline.Queue[pipeline.BuildEvent])
+		starts = make(pipeline.Queue[pipeline.StartEvent], 1)
+		status = pipeline.NewStatus()
+		done = make(chan error, 1)
+
+		runner := project.NewRunner(nil, project.NewEnvironment(nil, nil), GinkgoWriter, GinkgoWriter, false, nil)
+		go func(stage func() error, done chan<- error) {
+			done <- stage()
+		}(pipeline.Run(ctx, runner, in, make(pipeline.Queue[pipeline.StopEvent]), starts, status, time.Second), done)
+	})
+
+	AfterEach(func() {
+		ctxCancel()
+		Eventually(done).Should(Receive(Succeed()))
+	})
+
+	It("reports the started process", func() {
+		startTime := time.Now()
+		in <- pipeline.BuildEvent{Path: binary}
+
+		var startEvent pipeline.StartEvent
+		Eventually(starts).Should(Receive(&startEvent))
+		process := status.Snapshot().Process
+		Expect(process).ToNot(BeNil())
+		Expect(process.PID).To(Equal(startEvent.PID))
+		Expect(process.Binary).To(Equal(binary))
+		Expect(process.StartTime).To(BeTemporally(">=", startTime))
+	})
+
+	It("reports the hash of the binary once it is calculated", func() {
+		in <- pipeline.BuildEvent{Path: binary}
+
+		binarySHA256, err := project.CalculateContentDigest(binary)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() string {
+			process := status.Snapshot().Process
+			if process == nil {
+				return ""
+			}
+			return process.BinarySHA256
+		}).Should(Equal(binarySHA256))
+	})
+})
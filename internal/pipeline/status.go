@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+)
+
+// State describes what the pipeline is currently doing.
+type State string
+
+const (
+	// StateWatching indicates that there is no running program and that the
+	// pipeline is waiting for changes.
+	StateWatching State = "watching"
+
+	// StateBuilding indicates that the program is being built.
+	StateBuilding State = "building"
+
+	// StateRunning indicates that the program is running.
+	StateRunning State = "running"
+
+	// StateFailed indicates that the last build has failed.
+	StateFailed State = "failed"
+)
+
+// NewStatus creates a new Status.
+func NewStatus() *Status {
+	return &Status{}
+}
+
+// Status tracks the state of the pipeline stages, so that it can be
+// inspected from outside the pipeline. It is safe for concurrent use.
+type Status struct {
+	mu                sync.Mutex
+	watchPaused       bool
+	building          bool
+	lastBuildDuration time.Duration
+	lastBuildErr      error
+	lastAnalysisErr   error
+	process           *ProcessStatus
+}
+
+// ProcessStatus describes a running program.
+type ProcessStatus struct {
+	PID    int
+	Binary string
+	// BinarySHA256 is the SHA-256 hash of the content of the binary. It is
+	// not the source digest of the binary. It is empty until the hash has
+	// been calculated, which happens after the program has been started.
+	BinarySHA256 string
+	StartTime    time.Time
+}
+
+// Snapshot is a consistent view of the Status at a given point in time.
+type Snapshot struct {
+	State             State
+	WatchPaused       bool
+	LastBuildDuration time.Duration
+	LastBuildError    error
+	LastAnalysisError error
+	Process           *ProcessStatus
+}
+
+// Snapshot returns the current state of the pipeline.
+func (s *Status) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var state State
+	switch {
+	case s.building:
+		state = StateBuilding
+	case s.lastBuildErr != nil:
+		state = StateFailed
+	case s.process != nil:
+		state = StateRunning
+	default:
+		state = StateWatching
+	}
+
+	var process *ProcessStatus
+	if s.process != nil {
+		processCopy := *s.process
+		process = &processCopy
+	}
+	return Snapshot{
+		State:             state,
+		WatchPaused:       s.watchPaused,
+		LastBuildDuration: s.lastBuildDuration,
+		LastBuildError:    s.lastBuildErr,
+		LastAnalysisError: s.lastAnalysisErr,
+		Process:           process,
+	}
+}
+
+// IsWatchPaused returns whether the watch stage has been paused.
+func (s *Status) IsWatchPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.watchPaused
+}
+
+// LastBuildError returns the error of the last build, if it failed.
+func (s *Status) LastBuildError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastBuildErr
+}
+
+func (s *Status) setWatchPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchPaused = paused
+}
+
+func (s *Status) setBuilding() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.building = true
+}
+
+func (s *Status) setBuildResult(duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.building = false
+	s.lastBuildDuration = duration
+	s.lastBuildErr = err
+}
+
+func (s *Status) setLastAnalysisError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastAnalysisErr = err
+}
+
+func (s *Status) setProcess(process *ProcessStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.process = process
+}
+
+// setProcessBinarySHA256 sets the binary hash of the specified process,
+// unless that process is no longer the running one.
+func (s *Status) setProcessBinarySHA256(process *ProcessStatus, binarySHA256 string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.process == process {
+		s.process.BinarySHA256 = binarySHA256
+	}
+}
@@ -4,21 +4,58 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 
 	"github.com/mokiat/gocrane/internal/filesystem"
+	"github.com/mokiat/gocrane/internal/project"
 	"github.com/mokiat/gog/ds"
+	"golang.org/x/exp/maps"
 )
 
+const (
+	// pollInterval specifies how often directories that could not be
+	// watched, due to the watch limit of the operating system, are checked
+	// for changes.
+	pollInterval = time.Second
+
+	// contentCacheCapacity specifies the number of files for which content
+	// digests are kept, in order to detect writes that change nothing.
+	contentCacheCapacity = 4096
+
+	// contentCacheMaxFileSize specifies the size above which files are not
+	// hashed and are always considered changed.
+	contentCacheMaxFileSize = 4 * 1024 * 1024
+
+	// renameWindow specifies how soon after a rename a path needs to be
+	// created in the same directory to be considered the new path of the
+	// renamed one. The two events are reported together, so this only
+	// needs to cover delays in handling them.
+	renameWindow = 100 * time.Millisecond
+)
+
+// newFSWatcher creates the filesystem watcher of the Watch stage. Tests
+// replace it in order to simulate errors that are reported by the watcher.
+var newFSWatcher = fsnotify.NewWatcher
+
 func Watch(
 	ctx context.Context,
-	verbose bool,
 	dirs []string,
 	watchFilter *filesystem.FilterTree,
+	sourceFilter *filesystem.FilterTree,
+	resourceFilter *filesystem.FilterTree,
+	ignoreFilter *filesystem.FilterTree,
+	followSymlinks bool,
 	out Queue[ChangeEvent],
+	pauses Queue[PauseEvent],
+	status *Status,
 	bootstrapEvent *ChangeEvent,
 
 ) func() error {
@@ -30,51 +67,214 @@ func Watch(
 			}
 		}
 
-		watcher, err := fsnotify.NewWatcher()
+		watcher, err := newFSWatcher()
 		if err != nil {
 			return fmt.Errorf("failed to create filesystem watcher: %w", err)
 		}
 		defer watcher.Close()
 
 		proc := &watchProcess{
-			verbose:      verbose,
-			watcher:      watcher,
-			watchFilter:  watchFilter,
-			trackedPaths: ds.NewSet[string](1024),
+			logger:         slog.Default().With("stage", "watch"),
+			watcher:        watcher,
+			watchFilter:    watchFilter,
+			sourceFilter:   sourceFilter,
+			resourceFilter: resourceFilter,
+			ignoreFilter:   ignoreFilter,
+			followSymlinks: followSymlinks,
+			roots:          dirs,
+			contentCache:   project.NewContentCache(contentCacheCapacity, contentCacheMaxFileSize),
+			trackedPaths:   filesystem.NewPathTree[pathStamp](),
+			aliases:        newDirAliases(),
+			polledDirs:     make(map[string]*ds.Set[string]),
 		}
 
+		pollTicker := time.NewTicker(pollInterval)
+		defer pollTicker.Stop()
+
 		// Bootstrap watching.
 		for _, dir := range dirs {
-			proc.startWatching(dir)
+			proc.watchParentOfFile(dir)
+			proc.startWatching(dir, OpCreate)
 		}
 
+		paused := false
 		for {
 			select {
 			case <-ctx.Done():
 				return nil
+			case pauseEvent := <-pauses:
+				if pauseEvent.Paused != paused {
+					paused = pauseEvent.Paused
+					status.setWatchPaused(paused)
+					proc.logPauseChange(paused)
+				}
 			case event := <-watcher.Events:
-				changedPaths := proc.handleEvent(event)
-				if changedPaths != nil && !changedPaths.IsEmpty() {
+				watchEventsMetric.Inc()
+				// Events are still handled while paused, so that new folders
+				// are tracked, but changes are not reported.
+				changes := proc.handleEvent(event)
+				if !paused && len(changes) > 0 {
 					out.Push(ctx, ChangeEvent{
-						Paths: changedPaths.Items(),
+						Changes: changes,
+						Reasons: ReasonWatch,
+					})
+				}
+			case <-pollTicker.C:
+				changes := proc.poll()
+				if !paused && len(changes) > 0 {
+					out.Push(ctx, ChangeEvent{
+						Changes: changes,
+						Reasons: ReasonWatch,
 					})
 				}
 			case err := <-watcher.Errors:
-				proc.logFSWatchError(err)
+				if !errors.Is(err, fsnotify.ErrEventOverflow) {
+					proc.logFSWatchError(err)
+					continue
+				}
+				// Events have been lost, so the tracked paths can no longer
+				// be trusted and need to be compared against the filesystem.
+				watchRescansMetric.Inc()
+				proc.logOverflowRescan()
+				changes := proc.rescan()
+				if !paused && len(changes) > 0 {
+					out.Push(ctx, ChangeEvent{
+						Changes: changes,
+						Reasons: ReasonRescan,
+					})
+				}
 			}
 		}
 	}
 }
 
 type watchProcess struct {
-	verbose     bool
-	watcher     *fsnotify.Watcher
-	watchFilter *filesystem.FilterTree
+	logger         *slog.Logger
+	watcher        *fsnotify.Watcher
+	watchFilter    *filesystem.FilterTree
+	sourceFilter   *filesystem.FilterTree
+	resourceFilter *filesystem.FilterTree
+	ignoreFilter   *filesystem.FilterTree
+	followSymlinks bool
+	roots          []string
+
+	trackedPaths *filesystem.PathTree[pathStamp]
+	aliases      *dirAliases
+	contentCache *project.ContentCache
+
+	// polledDirs holds the directories that could not be watched, along
+	// with their entries as of the last poll.
+	polledDirs        map[string]*ds.Set[string]
+	watchLimitReached bool
+
+	// pendingRename holds the last rename that has not been matched with
+	// the creation of a new path yet. The operating system reports the new
+	// path of a renamed file as created, right after the rename of the old
+	// path, so this is used to tell the two apart.
+	pendingRename *pendingRename
+}
+
+// pendingRename describes a path that has been renamed.
+type pendingRename struct {
+	dir  string
+	time time.Time
+}
+
+// pathStamp captures the state of a tracked path at the time it was last
+// observed, so that missed changes can be detected during a rescan.
+type pathStamp struct {
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+func newPathStamp(info os.FileInfo) pathStamp {
+	return pathStamp{
+		isDir:   info.IsDir(),
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+}
+
+// IsModified returns whether the content of the path has changed between
+// the two stamps. Directories are not considered modified when only their
+// entries change, since entries are tracked on their own.
+func (s pathStamp) IsModified(other pathStamp) bool {
+	if s.isDir != other.isDir {
+		return true
+	}
+	if s.isDir {
+		return false
+	}
+	return s.size != other.size || !s.modTime.Equal(other.modTime)
+}
 
-	trackedPaths *ds.Set[string]
+// dirAliases maps the real paths of watched directories to the paths
+// through which they are tracked. Unless symlinks are followed, the two
+// are the same.
+type dirAliases struct {
+	realPaths map[string]string
+	aliases   map[string][]string
+}
+
+func newDirAliases() *dirAliases {
+	return &dirAliases{
+		realPaths: make(map[string]string),
+		aliases:   make(map[string][]string),
+	}
 }
 
-func (proc *watchProcess) handleEvent(event fsnotify.Event) *ds.Set[string] {
+// Add registers a directory that is tracked through path and returns
+// whether its real path has not been registered before.
+func (a *dirAliases) Add(path, realPath string) bool {
+	a.realPaths[path] = realPath
+	a.aliases[realPath] = append(a.aliases[realPath], path)
+	return len(a.aliases[realPath]) == 1
+}
+
+// Remove unregisters a directory that is tracked through path and returns
+// its real path, if it is no longer tracked through any other path.
+func (a *dirAliases) Remove(path string) (string, bool) {
+	realPath, ok := a.realPaths[path]
+	if !ok {
+		return "", false
+	}
+	delete(a.realPaths, path)
+	remaining := slices.DeleteFunc(a.aliases[realPath], func(alias string) bool {
+		return alias == path
+	})
+	if len(remaining) > 0 {
+		a.aliases[realPath] = remaining
+		return "", false
+	}
+	delete(a.aliases, realPath)
+	return realPath, true
+}
+
+// IsWatched returns whether the specified real path is tracked through
+// any path.
+func (a *dirAliases) IsWatched(realPath string) bool {
+	_, ok := a.aliases[realPath]
+	return ok
+}
+
+// Resolve maps a path that is reported by the watcher, which is within a
+// real path, to the paths through which it is tracked.
+func (a *dirAliases) Resolve(path string) []string {
+	if aliases, ok := a.aliases[filepath.Dir(path)]; ok {
+		result := make([]string, len(aliases))
+		for i, alias := range aliases {
+			result[i] = filepath.Join(alias, filepath.Base(path))
+		}
+		return result
+	}
+	if aliases, ok := a.aliases[path]; ok {
+		return slices.Clone(aliases)
+	}
+	return []string{path}
+}
+
+func (proc *watchProcess) handleEvent(event fsnotify.Event) []Change {
 	proc.logFSWatchEvent(event)
 
 	absPath, err := filesystem.ToAbsolutePath(event.Name)
@@ -83,37 +283,119 @@ func (proc *watchProcess) handleEvent(event fsnotify.Event) *ds.Set[string] {
 		return nil
 	}
 
+	renamedTo := event.Op.Has(fsnotify.Create) && proc.matchRename(event.Name)
+	if event.Op.Has(fsnotify.Rename) {
+		proc.pendingRename = &pendingRename{
+			dir:  filepath.Dir(event.Name),
+			time: time.Now(),
+		}
+	}
+
+	var result []Change
+	for _, path := range proc.aliases.Resolve(absPath) {
+		result = MergeChanges(result, proc.handlePathEvent(path, event.Op, renamedTo))
+	}
+	return result
+}
+
+// matchRename returns whether the specified path, which has been created,
+// is the new path of the last renamed path. A rename is matched at most once
+// and only with a path in the same directory that is created shortly after.
+func (proc *watchProcess) matchRename(path string) bool {
+	rename := proc.pendingRename
+	if rename == nil || rename.dir != filepath.Dir(path) {
+		return false
+	}
+	proc.pendingRename = nil
+	return time.Since(rename.time) <= renameWindow
+}
+
+func (proc *watchProcess) handlePathEvent(absPath string, op fsnotify.Op, renamedTo bool) []Change {
 	if !proc.shouldTrack(absPath) {
 		proc.logExcludedPathWatchSkip(absPath)
 		return nil
 	}
 
 	switch {
-	case event.Has(fsnotify.Create):
-		return proc.startWatching(absPath)
+	case op.Has(fsnotify.Create):
+		if proc.isTracked(absPath) {
+			// The path has been replaced, usually by an editor that saves
+			// atomically by renaming a temporary file over the original.
+			return proc.replacePath(absPath)
+		}
+		if renamedTo {
+			return proc.startWatching(absPath, OpRenameTo)
+		}
+		return proc.startWatching(absPath, OpCreate)
 
-	case event.Has(fsnotify.Rename):
+	case op.Has(fsnotify.Rename):
 		// Rename is produced on Linux when a file is deleted.
-		return proc.stopWatching(absPath)
+		return proc.stopWatching(absPath, OpRename)
 
-	case event.Has(fsnotify.Remove):
-		return proc.stopWatching(absPath)
+	case op.Has(fsnotify.Remove):
+		return proc.stopWatching(absPath, OpRemove)
 
-	case event.Has(fsnotify.Chmod):
+	case op.Has(fsnotify.Chmod):
 		// We do nothing on these, since MacOS produces a lot of them.
 		return nil
 
 	default:
-		return ds.SetFromSlice([]string{absPath})
+		return proc.modifyPath(absPath)
+	}
+}
+
+func (proc *watchProcess) modifyPath(path string) []Change {
+	proc.restampPath(path)
+	if !proc.isContentChanged(path) {
+		watchUnchangedWritesMetric.Inc()
+		proc.logUnchangedContentSkip(path)
+		return nil
+	}
+	return []Change{{Path: path, Op: OpModify}}
+}
+
+func (proc *watchProcess) replacePath(path string) []Change {
+	info, err := proc.stat(path)
+	if err != nil {
+		// The path is already gone and a removal event will follow.
+		return nil
 	}
+	if stamp, _ := proc.trackedPaths.Get(path); info.IsDir() || stamp.isDir {
+		// The old tree is no longer the one that is being watched.
+		return MergeChanges(
+			proc.stopWatching(path, OpRemove),
+			proc.startWatching(path, OpCreate),
+		)
+	}
+	return proc.modifyPath(path)
+}
+
+func (proc *watchProcess) startWatching(root string, op Op) []Change {
+	paths := proc.trackTree(root)
+	for _, path := range paths {
+		proc.logStartWatching(path)
+	}
+	return changesOf(paths, op)
 }
 
-func (proc *watchProcess) startWatching(root string) *ds.Set[string] {
-	result := ds.NewSet[string](1)
+func (proc *watchProcess) trackTree(root string) []string {
+	var (
+		result []string
+		files  []string
+	)
 
-	filesystem.Traverse(root, func(p string, isDir bool, err error) error {
+	traverse := filesystem.Traverse
+	if proc.followSymlinks {
+		traverse = filesystem.TraverseSymlinks
+	}
+
+	traverse(root, func(p string, isDir bool, err error) error {
 		if err != nil {
-			proc.logTraverseError(p, err)
+			if errors.Is(err, filesystem.ErrSymlinkCycle) {
+				proc.logSymlinkCycleSkip(p)
+			} else {
+				proc.logTraverseError(p, err)
+			}
 			return filesystem.ErrSkip
 		}
 
@@ -131,101 +413,358 @@ func (proc *watchProcess) startWatching(root string) *ds.Set[string] {
 			return filesystem.ErrSkip
 		}
 
-		if isDir {
-			if err := proc.watcher.Add(absPath); err != nil {
+		if !isDir {
+			files = append(files, absPath)
+			return nil
+		}
+
+		if err := proc.watchDir(absPath); err != nil {
+			if !errors.Is(err, syscall.ENOSPC) {
 				proc.logFSWatchAddError(absPath, err)
 				return filesystem.ErrSkip
 			}
+			// The watch limit has been reached. Rather than missing
+			// changes, the directory is checked for changes periodically.
+			proc.logPollFallback(absPath)
+			proc.polledDirs[absPath] = proc.listDir(absPath)
 		}
 
-		proc.trackPath(absPath)
-		result.Add(absPath)
+		// Directories are never considered modified, so their stamp
+		// does not need any information besides their type.
+		proc.trackPath(absPath, pathStamp{isDir: true})
+		result = append(result, absPath)
 		return nil
 	})
 
-	for path := range result.Unbox() {
-		proc.logStartWatching(path)
+	// Files are stamped after the traversal, so that they can be inspected
+	// concurrently.
+	infos, errs := filesystem.StatAll(files, proc.stat)
+	for i, file := range files {
+		if errs[i] != nil {
+			proc.logTraverseError(file, errs[i])
+			continue
+		}
+		proc.trackPath(file, newPathStamp(infos[i]))
+		result = append(result, file)
 	}
+
+	slices.Sort(result)
 	return result
 }
 
-func (proc *watchProcess) stopWatching(root string) *ds.Set[string] {
-	result := ds.NewSet[string](1)
+func (proc *watchProcess) stopWatching(root string, op Op) []Change {
+	// The paths are collected first, as the tree cannot be modified while
+	// it is being iterated.
+	stamps := make(map[string]pathStamp)
+	for p, stamp := range proc.trackedPaths.Subtree(root) {
+		stamps[p] = stamp
+	}
 
-	for p := range proc.trackedPaths.Unbox() {
-		if strings.HasPrefix(p, root) {
-			result.Add(p)
-			err := proc.watcher.Remove(p)
-			if err == nil || errors.Is(err, fsnotify.ErrNonExistentWatch) {
-				proc.untrackPath(p)
-			} else {
-				proc.logFSWatchRemoveError(p, err)
-			}
+	result := make([]string, 0, len(stamps))
+	for p, stamp := range stamps {
+		result = append(result, p)
+		err := proc.unwatchDir(p, stamp)
+		if err == nil || errors.Is(err, fsnotify.ErrNonExistentWatch) {
+			proc.untrackPath(p)
+		} else {
+			proc.logFSWatchRemoveError(p, err)
 		}
 	}
 
-	for path := range result.Unbox() {
+	slices.Sort(result)
+	for _, path := range result {
 		proc.logStopWatching(path)
 	}
+	return changesOf(result, op)
+}
+
+// rescan traverses all roots anew and returns the paths that have appeared,
+// disappeared, or have been modified since they were last observed.
+func (proc *watchProcess) rescan() []Change {
+	previous := proc.trackedPaths
+	previousAliases := proc.aliases
+	proc.trackedPaths = filesystem.NewPathTree[pathStamp]()
+	proc.aliases = newDirAliases()
+	proc.polledDirs = make(map[string]*ds.Set[string])
+	for _, root := range proc.roots {
+		proc.trackTree(root)
+	}
+
+	var result []Change
+	for path, stamp := range proc.trackedPaths.All() {
+		previousStamp, ok := previous.Get(path)
+		switch {
+		case !ok:
+			result = append(result, Change{Path: path, Op: OpCreate})
+		case stamp.IsModified(previousStamp):
+			result = append(result, Change{Path: path, Op: OpModify})
+		}
+	}
+	for path, stamp := range previous.All() {
+		if proc.trackedPaths.Contains(path) {
+			continue
+		}
+		result = append(result, Change{Path: path, Op: OpRemove})
+		realPath := previousAliases.realPaths[path]
+		if stamp.isDir && !proc.aliases.IsWatched(realPath) {
+			// The watch of a deleted directory is usually already dropped by
+			// the operating system, so any error here is expected.
+			_ = proc.watcher.Remove(realPath)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b Change) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	proc.logRescanResult(proc.trackedPaths.Len(), len(result))
+	return result
+}
+
+// poll checks the directories that could not be watched and returns the
+// paths that have appeared, disappeared, or have been modified since the
+// last poll.
+func (proc *watchProcess) poll() []Change {
+	var result []Change
+	for _, dir := range maps.Keys(proc.polledDirs) {
+		previousEntries, ok := proc.polledDirs[dir]
+		if !ok {
+			continue // removed while handling a parent directory
+		}
+		entries := proc.listDir(dir)
+		for entry := range previousEntries.Unbox() {
+			if !entries.Contains(entry) {
+				result = MergeChanges(result, proc.stopWatching(entry, OpRemove))
+			}
+		}
+		for entry := range entries.Unbox() {
+			switch {
+			case !proc.isTracked(entry):
+				result = MergeChanges(result, proc.startWatching(entry, OpCreate))
+			case proc.restampPath(entry) && proc.isContentChanged(entry):
+				result = MergeChanges(result, []Change{{Path: entry, Op: OpModify}})
+			}
+		}
+		if _, ok := proc.polledDirs[dir]; ok {
+			proc.polledDirs[dir] = entries
+		}
+	}
+	return result
+}
+
+// listDir returns the entries of the specified directory that should be
+// tracked. A directory that cannot be read is considered empty.
+func (proc *watchProcess) listDir(dir string) *ds.Set[string] {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ds.NewSet[string](0)
+	}
+	result := ds.NewSet[string](len(entries))
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if proc.shouldTrack(path) {
+			result.Add(path)
+		}
+	}
+	return result
+}
+
+// watchParentOfFile watches the parent directory of the specified path if
+// it is a file or does not exist yet. Files are not watched directly, as
+// editors often replace them on save, which would end the watch, and paths
+// that do not exist yet can only be noticed once they are created in their
+// parent directory. Events for other entries in the directory are discarded
+// by the watch filter.
+func (proc *watchProcess) watchParentOfFile(path string) {
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		return
+	}
+	if err != nil {
+		proc.logMissingRootWatchParent(path, err)
+	}
+	parent := filepath.Dir(path)
+	if err := proc.watcher.Add(parent); err != nil {
+		proc.logFSWatchAddError(parent, err)
+	}
+}
+
+// watchDir starts watching the directory at the specified path. When
+// symlinks are followed, the real path of the directory is watched, once
+// for all paths through which it can be reached.
+func (proc *watchProcess) watchDir(path string) error {
+	realPath := path
+	if proc.followSymlinks {
+		var err error
+		if realPath, err = filepath.EvalSymlinks(path); err != nil {
+			return err
+		}
+	}
+	if !proc.aliases.Add(path, realPath) {
+		return nil
+	}
+	err := proc.watcher.Add(realPath)
+	if err != nil && !errors.Is(err, syscall.ENOSPC) {
+		proc.aliases.Remove(path)
+	}
+	return err
+}
+
+// unwatchDir stops watching the directory at the specified path, unless
+// its real path is still reached through another path.
+func (proc *watchProcess) unwatchDir(path string, stamp pathStamp) error {
+	if !stamp.isDir {
+		return nil
+	}
+	realPath, ok := proc.aliases.Remove(path)
+	if !ok {
+		return nil
+	}
+	return proc.watcher.Remove(realPath)
+}
+
+// stat returns information on the specified path, following a symlink
+// at the path if symlinks are followed and it is not broken.
+func (proc *watchProcess) stat(path string) (os.FileInfo, error) {
+	if proc.followSymlinks {
+		if info, err := os.Stat(path); err == nil {
+			return info, nil
+		}
+	}
+	return os.Lstat(path)
+}
+
+// changesOf returns changes with the specified operation for all of the
+// specified paths.
+func changesOf(paths []string, op Op) []Change {
+	result := make([]Change, len(paths))
+	for i, path := range paths {
+		result[i] = Change{Path: path, Op: op}
+	}
 	return result
 }
 
 func (proc *watchProcess) shouldTrack(path string) bool {
-	return proc.watchFilter.IsAccepted(path)
+	return proc.watchFilter.IsAccepted(path) && !proc.ignoreFilter.IsAccepted(path)
 }
 
-func (proc *watchProcess) trackPath(path string) {
-	proc.trackedPaths.Add(path)
+// isContentChanged returns whether the content of a source or resource file
+// differs from when it was last changed. Other paths, as well as files
+// whose previous content is not known, are considered changed.
+func (proc *watchProcess) isContentChanged(path string) bool {
+	if !proc.sourceFilter.IsAccepted(path) && !proc.resourceFilter.IsAccepted(path) {
+		return true
+	}
+	changed, err := proc.contentCache.Update(path)
+	if err != nil {
+		proc.logContentHashError(path, err)
+		return true
+	}
+	return changed
+}
+
+func (proc *watchProcess) trackPath(path string, stamp pathStamp) {
+	proc.trackedPaths.Set(path, stamp)
+}
+
+// restampPath updates the stamp of a tracked path and returns whether
+// the path has been modified since it was last observed.
+func (proc *watchProcess) restampPath(path string) bool {
+	stamp, ok := proc.trackedPaths.Get(path)
+	if !ok {
+		return false
+	}
+	info, err := proc.stat(path)
+	if err != nil {
+		return false
+	}
+	newStamp := newPathStamp(info)
+	proc.trackedPaths.Set(path, newStamp)
+	return newStamp.IsModified(stamp)
 }
 
 func (proc *watchProcess) untrackPath(path string) {
-	proc.trackedPaths.Remove(path)
+	proc.trackedPaths.Delete(path)
+	delete(proc.polledDirs, path)
+	proc.contentCache.Remove(path)
 }
 
 func (proc *watchProcess) isTracked(path string) bool {
 	return proc.trackedPaths.Contains(path)
 }
 
-func (proc *watchProcess) logFSWatchEvent(event fsnotify.Event) {
-	if proc.verbose {
-		log.Printf("Filesystem watch event: %s", event)
+func (proc *watchProcess) logPauseChange(paused bool) {
+	if paused {
+		proc.logger.Info("Paused watching, changes will be ignored.")
+	} else {
+		proc.logger.Info("Resumed watching.")
 	}
 }
 
+func (proc *watchProcess) logFSWatchEvent(event fsnotify.Event) {
+	proc.logger.Debug("Filesystem watch event.", "path", event.Name, "op", event.Op.String())
+}
+
+func (proc *watchProcess) logMissingRootWatchParent(path string, err error) {
+	proc.logger.Debug("Path is not accessible, watching its parent folder.", "path", path, "error", err)
+}
+
 func (proc *watchProcess) logFSWatchAddError(path string, err error) {
-	log.Printf("Error adding watch to %q: %v", path, err)
+	proc.logger.Error("Error adding watch.", "path", path, "error", err)
 }
 
 func (proc *watchProcess) logFSWatchRemoveError(path string, err error) {
-	log.Printf("Error removing watch from %q: %v", path, err)
+	proc.logger.Error("Error removing watch.", "path", path, "error", err)
 }
 
 func (proc *watchProcess) logFSWatchError(err error) {
-	log.Printf("Filesystem watch error: %v", err)
+	proc.logger.Error("Filesystem watch error.", "error", err)
 }
 
-func (proc *watchProcess) logStartWatching(path string) {
-	if proc.verbose {
-		log.Printf("Now watching %q", path)
+func (proc *watchProcess) logOverflowRescan() {
+	proc.logger.Warn("Filesystem events were lost, rescanning watched paths.")
+}
+
+func (proc *watchProcess) logRescanResult(trackedCount, changedCount int) {
+	proc.logger.Info("Rescanned watched paths.", "tracked", trackedCount, "changed", changedCount)
+}
+
+func (proc *watchProcess) logPollFallback(path string) {
+	if !proc.watchLimitReached {
+		proc.watchLimitReached = true
+		proc.logger.Warn("Reached the filesystem watch limit, polling directories for changes instead.", "path", path)
+		return
 	}
+	proc.logger.Debug("Polling directory for changes.", "path", path)
+}
+
+func (proc *watchProcess) logUnchangedContentSkip(path string) {
+	proc.logger.Debug("Skipping write that did not change the content.", "path", path)
+}
+
+func (proc *watchProcess) logContentHashError(path string, err error) {
+	proc.logger.Debug("Error hashing content.", "path", path, "error", err)
+}
+
+func (proc *watchProcess) logStartWatching(path string) {
+	proc.logger.Debug("Now watching.", "path", path)
 }
 
 func (proc *watchProcess) logStopWatching(path string) {
-	if proc.verbose {
-		log.Printf("No longer watching %q", path)
-	}
+	proc.logger.Debug("No longer watching.", "path", path)
+}
+
+func (proc *watchProcess) logSymlinkCycleSkip(path string) {
+	proc.logger.Warn("Skipping symlink that points to a parent folder.", "path", path)
 }
 
 func (proc *watchProcess) logTraverseError(path string, err error) {
-	log.Printf("Error traversing %q: %v", path, err)
+	proc.logger.Error("Error traversing.", "path", path, "error", err)
 }
 
 func (proc *watchProcess) logPathAbsConvertError(path string, err error) {
-	log.Printf("Error converting path %q to absolute: %v", path, err)
+	proc.logger.Error("Error converting path to absolute.", "path", path, "error", err)
 }
 
 func (proc *watchProcess) logExcludedPathWatchSkip(path string) {
-	if proc.verbose {
-		log.Printf("Skipping excluded path %q from processing", path)
-	}
+	proc.logger.Debug("Skipping excluded path from processing.", "path", path)
 }
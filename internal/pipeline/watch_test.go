@@ -0,0 +1,326 @@
+package pipeline_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
+	"github.com/mokiat/gocrane/internal/pipeline"
+)
+
+var _ = Describe("Watch", func() {
+	var (
+		ctx       context.Context
+		ctxCancel func()
+		dir       string
+		out       pipeline.Queue[pipeline.ChangeEvent]
+		changes   map[string]pipeline.Op
+		history   []pipeline.Change
+	)
+
+	// receive records the latest operation of each path in the events that
+	// have been produced so far, as well as all changes in order.
+	receive := func() map[string]pipeline.Op {
+		for {
+			select {
+			case event := <-out:
+				for _, change := range event.Changes {
+					changes[change.Path] = change.Op
+				}
+				history = append(history, event.Changes...)
+			default:
+				return changes
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		ctx, ctxCancel = context.WithCancel(context.Background())
+		var err error
+		dir, err = filepath.EvalSymlinks(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		out = make(pipeline.Queue[pipeline.ChangeEvent], 1024)
+		changes = make(map[string]pipeline.Op)
+		history = nil
+	})
+
+	AfterEach(func() {
+		ctxCancel()
+	})
+
+	// startStage starts watching the specified roots, which default to the
+	// temporary directory. The temporary directory is always accepted by the
+	// watch filter.
+	startStage := func(roots ...string) {
+		if len(roots) == 0 {
+			roots = []string{dir}
+		}
+		watchFilter := filesystem.NewFilterTree()
+		watchFilter.AcceptPath(dir)
+		sourceFilter := filesystem.NewFilterTree()
+		sourceFilter.AcceptGlob("*.go")
+		ignoreFilter := filesystem.NewFilterTree()
+		ignoreFilter.AcceptGlob("*.swp")
+		ignoreFilter.AcceptGlob("4913")
+		ignoreFilter.AcceptGlob("*___jb_tmp___")
+		go pipeline.Watch(ctx, roots,
+			watchFilter,
+			sourceFilter,
+			filesystem.NewFilterTree(),
+			ignoreFilter,
+			false,
+			out,
+			make(pipeline.Queue[pipeline.PauseEvent]),
+			pipeline.NewStatus(),
+			nil,
+		)()
+
+		// The stage does not report when it has started watching, so a
+		// probe file is written until its changes are observed.
+		probe := filepath.Join(dir, "probe")
+		Eventually(func(g Gomega) {
+			g.Expect(os.WriteFile(probe, []byte(time.Now().String()), 0o644)).To(Succeed())
+			g.Eventually(receive).WithTimeout(100 * time.Millisecond).Should(HaveKey(probe))
+		}).Should(Succeed())
+	}
+
+	When("a large tree is deleted", func() {
+		var (
+			apiDir      string
+			apiPaths    []string
+			siblingFile string
+		)
+
+		BeforeEach(func() {
+			apiDir = filepath.Join(dir, "api")
+			apiPaths = []string{apiDir}
+			for i := range 10 {
+				pkgDir := filepath.Join(apiDir, fmt.Sprintf("pkg%d", i))
+				Expect(os.MkdirAll(pkgDir, 0o755)).To(Succeed())
+				apiPaths = append(apiPaths, pkgDir)
+				for j := range 10 {
+					file := filepath.Join(pkgDir, fmt.Sprintf("file%d.go", j))
+					Expect(os.WriteFile(file, nil, 0o644)).To(Succeed())
+					apiPaths = append(apiPaths, file)
+				}
+			}
+			siblingFile = filepath.Join(dir, "api2", "handler.go")
+			Expect(os.MkdirAll(filepath.Dir(siblingFile), 0o755)).To(Succeed())
+			Expect(os.WriteFile(siblingFile, nil, 0o644)).To(Succeed())
+
+			startStage()
+			Expect(os.RemoveAll(apiDir)).To(Succeed())
+		})
+
+		It("reports the removal of all paths in the tree", func() {
+			Eventually(func(g Gomega) {
+				received := receive()
+				for _, path := range apiPaths {
+					g.Expect(received).To(HaveKeyWithValue(path, pipeline.OpRemove))
+				}
+			}).Should(Succeed())
+		})
+
+		It("keeps watching siblings that share a name prefix", func() {
+			Consistently(receive).ShouldNot(Or(
+				HaveKey(filepath.Dir(siblingFile)),
+				HaveKey(siblingFile),
+			))
+			Expect(os.WriteFile(siblingFile, []byte("package api2"), 0o644)).To(Succeed())
+			Eventually(receive).Should(HaveKeyWithValue(siblingFile, pipeline.OpModify))
+		})
+	})
+
+	When("a root does not exist yet", func() {
+		var appDir string
+
+		BeforeEach(func() {
+			appDir = filepath.Join(dir, "app")
+			startStage(appDir)
+		})
+
+		It("starts watching the root once it is created", func() {
+			Expect(os.Mkdir(appDir, 0o755)).To(Succeed())
+			Eventually(receive).Should(HaveKeyWithValue(appDir, pipeline.OpCreate))
+
+			mainFile := filepath.Join(appDir, "main.go")
+			Eventually(func(g Gomega) {
+				g.Expect(os.WriteFile(mainFile, []byte(time.Now().String()), 0o644)).To(Succeed())
+				g.Eventually(receive).WithTimeout(100 * time.Millisecond).Should(HaveKey(mainFile))
+			}).Should(Succeed())
+		})
+	})
+	When("filesystem events are lost", func() {
+		var (
+			watcher      *fsnotify.Watcher
+			createdFile  string
+			modifiedFile string
+			deletedFile  string
+		)
+
+		BeforeEach(func() {
+			createdFile = filepath.Join(dir, "created.go")
+			modifiedFile = filepath.Join(dir, "modified.go")
+			deletedFile = filepath.Join(dir, "deleted.go")
+			Expect(os.WriteFile(modifiedFile, nil, 0o644)).To(Succeed())
+			Expect(os.WriteFile(deletedFile, nil, 0o644)).To(Succeed())
+
+			watchers, restore := pipeline.InterceptWatchers()
+			DeferCleanup(restore)
+			startStage()
+			Eventually(watchers).Should(Receive(&watcher))
+
+			// Events of the probe file that are still pending would otherwise
+			// be reported by the rescan as well.
+			Eventually(func(g Gomega) {
+				g.Consistently(out).WithTimeout(100 * time.Millisecond).ShouldNot(Receive())
+			}).Should(Succeed())
+
+			// The changes are made while the directory is not watched, so
+			// that they are only found by rescanning.
+			Expect(watcher.Remove(dir)).To(Succeed())
+			Expect(os.WriteFile(createdFile, nil, 0o644)).To(Succeed())
+			Expect(os.WriteFile(modifiedFile, []byte("package main"), 0o644)).To(Succeed())
+			Expect(os.Remove(deletedFile)).To(Succeed())
+			watcher.Errors <- fsnotify.ErrEventOverflow
+		})
+
+		It("reports the missed changes in a single rescan event", func() {
+			var event pipeline.ChangeEvent
+			Eventually(out).Should(Receive(&event, HaveField("Reasons", pipeline.ReasonRescan)))
+			Expect(event.Changes).To(ConsistOf(
+				pipeline.Change{Path: createdFile, Op: pipeline.OpCreate},
+				pipeline.Change{Path: modifiedFile, Op: pipeline.OpModify},
+				pipeline.Change{Path: deletedFile, Op: pipeline.OpRemove},
+			))
+		})
+
+		It("keeps watching the rescanned paths", func() {
+			Eventually(out).Should(Receive(HaveField("Reasons", pipeline.ReasonRescan)))
+			Expect(os.WriteFile(createdFile, []byte("package main"), 0o644)).To(Succeed())
+			Eventually(receive).Should(HaveKeyWithValue(createdFile, pipeline.OpModify))
+		})
+	})
+	When("an editor saves a file", func() {
+		var mainFile string
+
+		// historyOf returns the changes that have been produced so far for
+		// the specified path.
+		historyOf := func(path string) []pipeline.Change {
+			receive()
+			var result []pipeline.Change
+			for _, change := range history {
+				if change.Path == path {
+					result = append(result, change)
+				}
+			}
+			return result
+		}
+
+		BeforeEach(func() {
+			mainFile = filepath.Join(dir, "main.go")
+			Expect(os.WriteFile(mainFile, []byte("package main"), 0o644)).To(Succeed())
+			startStage()
+		})
+
+		It("ignores the temporary files of the editor", func() {
+			tempFiles := []string{
+				filepath.Join(dir, ".main.go.swp"),
+				filepath.Join(dir, "4913"),
+				filepath.Join(dir, "main.go___jb_tmp___"),
+			}
+			for _, file := range tempFiles {
+				Expect(os.WriteFile(file, nil, 0o644)).To(Succeed())
+				Expect(os.WriteFile(file, []byte("temporary"), 0o644)).To(Succeed())
+				Expect(os.Remove(file)).To(Succeed())
+			}
+			Expect(os.WriteFile(mainFile, []byte("package main\n"), 0o644)).To(Succeed())
+			Eventually(receive).Should(HaveKeyWithValue(mainFile, pipeline.OpModify))
+			for _, file := range tempFiles {
+				Expect(historyOf(file)).To(BeEmpty())
+			}
+		})
+
+		It("reports a file that is replaced through a rename as modified once", func() {
+			tempFile := mainFile + "___jb_tmp___"
+			Expect(os.WriteFile(tempFile, []byte("package main\n"), 0o644)).To(Succeed())
+			Expect(os.Rename(tempFile, mainFile)).To(Succeed())
+			Eventually(historyOf).WithArguments(mainFile).ShouldNot(BeEmpty())
+			Consistently(historyOf).WithArguments(mainFile).Should(Equal([]pipeline.Change{
+				{Path: mainFile, Op: pipeline.OpModify},
+			}))
+			Expect(historyOf(tempFile)).To(BeEmpty())
+		})
+	})
+	When("a file is renamed", func() {
+		var (
+			oldFile string
+			newFile string
+		)
+
+		BeforeEach(func() {
+			oldFile = filepath.Join(dir, "old.go")
+			newFile = filepath.Join(dir, "new.go")
+			Expect(os.WriteFile(oldFile, []byte("package main"), 0o644)).To(Succeed())
+			startStage()
+			Expect(os.Rename(oldFile, newFile)).To(Succeed())
+		})
+
+		It("reports both the old and the new path", func() {
+			Eventually(receive).Should(And(
+				HaveKeyWithValue(oldFile, pipeline.OpRename),
+				HaveKeyWithValue(newFile, pipeline.OpRenameTo),
+			))
+		})
+	})
+	When("a file is created after an unrelated rename", func() {
+		var (
+			movedFile   string
+			createdFile string
+		)
+
+		BeforeEach(func() {
+			movedFile = filepath.Join(dir, "sub", "moved.go")
+			createdFile = filepath.Join(dir, "created.go")
+			Expect(os.MkdirAll(filepath.Dir(movedFile), 0o755)).To(Succeed())
+			Expect(os.WriteFile(movedFile, []byte("package sub"), 0o644)).To(Succeed())
+			startStage()
+		})
+
+		It("reports a file created in another directory as created", func() {
+			Expect(os.Rename(movedFile, filepath.Join(GinkgoT().TempDir(), "moved.go"))).To(Succeed())
+			Expect(os.WriteFile(createdFile, nil, 0o644)).To(Succeed())
+			Eventually(receive).Should(And(
+				HaveKeyWithValue(movedFile, pipeline.OpRename),
+				HaveKeyWithValue(createdFile, pipeline.OpCreate),
+			))
+		})
+
+		It("reports a file created later in the same directory as created", func() {
+			laterFile := filepath.Join(filepath.Dir(movedFile), "later.go")
+			Expect(os.Rename(movedFile, filepath.Join(GinkgoT().TempDir(), "moved.go"))).To(Succeed())
+			Eventually(receive).Should(HaveKeyWithValue(movedFile, pipeline.OpRename))
+			time.Sleep(200 * time.Millisecond)
+			Expect(os.WriteFile(laterFile, nil, 0o644)).To(Succeed())
+			Eventually(receive).Should(HaveKeyWithValue(laterFile, pipeline.OpCreate))
+		})
+
+		It("matches a rename with a single new path", func() {
+			renamedFile := filepath.Join(filepath.Dir(movedFile), "renamed.go")
+			siblingFile := filepath.Join(filepath.Dir(movedFile), "sibling.go")
+			Expect(os.Rename(movedFile, renamedFile)).To(Succeed())
+			Expect(os.WriteFile(siblingFile, nil, 0o644)).To(Succeed())
+			Eventually(receive).Should(And(
+				HaveKeyWithValue(renamedFile, pipeline.OpRenameTo),
+				HaveKeyWithValue(siblingFile, pipeline.OpCreate),
+			))
+		})
+	})
+})
@@ -0,0 +1,141 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+)
+
+// DefaultAnalysisCommand is the command that is used to analyze packages
+// when one is not specified.
+var DefaultAnalysisCommand = []string{"go", "vet"}
+
+// NewAnalyzer creates a new Analyzer that runs the specified command from
+// the specified directory and with the specified environment, which should
+// match the one of the build. The packages to analyze are appended to the
+// command arguments.
+func NewAnalyzer(runDir string, command []string, env *Environment) *Analyzer {
+	if len(command) == 0 {
+		command = DefaultAnalysisCommand
+	}
+	return &Analyzer{
+		runDir:  runDir,
+		command: command,
+		env:     env,
+	}
+}
+
+// Analyzer runs static analysis (e.g. go vet) on packages.
+type Analyzer struct {
+	runDir  string
+	command []string
+	env     *Environment
+}
+
+// Analyze runs the analysis command on the packages in the specified
+// directories. If the command reports problems, an *AnalysisError is
+// returned. If the command fails without reporting any problems, a
+// different error is returned.
+func (a *Analyzer) Analyze(ctx context.Context, packageDirs []string) error {
+	workDir, err := filepath.Abs(a.runDir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute directory for %q: %w", a.runDir, err)
+	}
+
+	args := slices.Clone(a.command[1:])
+	for _, dir := range packageDirs {
+		args = append(args, packageArg(workDir, dir))
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, a.command[0], args...)
+	cmd.Dir = a.runDir
+	cmd.Env = append(os.Environ(), a.env.Load()...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	runErr := cmd.Run()
+
+	diagnostics := ParseDiagnostics(output.String(), workDir, findModuleRoot(workDir))
+	logDiagnostics("analyzer", output.String(), diagnostics)
+
+	var exitErr *exec.ExitError
+	switch {
+	case runErr == nil:
+		return nil
+	case errors.As(runErr, &exitErr) && len(diagnostics) == 0:
+		// The command failed without reporting any problems in the code
+		// (e.g. because a package could not be loaded), so this is not a
+		// finding about the build.
+		return fmt.Errorf("%s failed without reporting problems: %w", a.command[0], runErr)
+	case errors.As(runErr, &exitErr):
+		return &AnalysisError{
+			Output:      output.String(),
+			Diagnostics: diagnostics,
+			err:         runErr,
+		}
+	default:
+		return fmt.Errorf("failed to run %s: %w", a.command[0], runErr)
+	}
+}
+
+// AnalysisError indicates that the analysis has reported problems.
+type AnalysisError struct {
+
+	// Output holds the output that was produced by the analysis command.
+	Output string
+
+	// Diagnostics holds the problems that were reported by the analysis
+	// command.
+	Diagnostics []Diagnostic
+
+	err error
+}
+
+func (e *AnalysisError) Error() string {
+	return fmt.Sprintf("analysis reported problems: %v", e.err)
+}
+
+func (e *AnalysisError) Unwrap() error {
+	return e.err
+}
+
+// AffectedPackageDirs returns the directories of the packages that contain
+// the specified Go source files. Directories that no longer contain any Go
+// files (e.g. because the files were deleted) are omitted.
+func AffectedPackageDirs(paths []string) []string {
+	var result []string
+	for _, path := range paths {
+		if filepath.Ext(path) != ".go" {
+			continue
+		}
+		dir := filepath.Dir(path)
+		if slices.Contains(result, dir) {
+			continue
+		}
+		if matches, _ := filepath.Glob(filepath.Join(dir, "*.go")); len(matches) == 0 {
+			continue
+		}
+		result = append(result, dir)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// packageArg returns the argument that identifies the package in the
+// specified directory for commands that run from workDir.
+func packageArg(workDir, dir string) string {
+	relDir := relativeFile(workDir, dir)
+	switch {
+	case filepath.IsAbs(relDir):
+		return relDir
+	case relDir == ".":
+		return "./"
+	default:
+		return "./" + relDir
+	}
+}
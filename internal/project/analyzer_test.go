@@ -0,0 +1,36 @@
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("AffectedPackageDirs", func() {
+	var rootDir string
+
+	BeforeEach(func() {
+		rootDir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(rootDir, "api"), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(rootDir, "empty"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(rootDir, "main.go"), nil, 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(rootDir, "api", "server.go"), nil, 0o644)).To(Succeed())
+	})
+
+	It("returns the sorted unique directories of changed Go files", func() {
+		Expect(project.AffectedPackageDirs([]string{
+			filepath.Join(rootDir, "main.go"),
+			filepath.Join(rootDir, "api", "server.go"),
+			filepath.Join(rootDir, "api", "handler.go"),
+			filepath.Join(rootDir, "README.md"),
+			filepath.Join(rootDir, "empty", "deleted.go"),
+		})).To(Equal([]string{
+			rootDir,
+			filepath.Join(rootDir, "api"),
+		}))
+	})
+})
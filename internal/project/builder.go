@@ -1,25 +1,31 @@
 package project
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/mokiat/gocrane/internal/logutil"
 )
 
-func NewBuilder(runDir string, args []string) *Builder {
+func NewBuilder(runDir string, args []string, env *Environment) *Builder {
 	return &Builder{
 		runDir: runDir,
 		args:   args,
+		env:    env,
 	}
 }
 
 type Builder struct {
 	runDir string
 	args   []string
+	env    *Environment
 }
 
 func (b *Builder) Build(ctx context.Context, destination string) error {
@@ -27,19 +33,97 @@ func (b *Builder) Build(ctx context.Context, destination string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get absolute destination for %q: %w", destination, err)
 	}
+	workDir, err := filepath.Abs(b.runDir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute directory for %q: %w", b.runDir, err)
+	}
 
 	args := append([]string{"build"}, b.args...)
 	args = append(args, "-o", absDestination, "./")
 
-	logger := log.New(log.Writer(), "[compiler]: ", log.Ltime|log.Lmsgprefix)
-
+	var output bytes.Buffer
 	cmd := exec.CommandContext(ctx, "go", args...)
 	cmd.Dir = b.runDir
-	cmd.Stdout = logutil.ToWriter(logger)
-	cmd.Stderr = logutil.ToWriter(logger)
+	cmd.Env = append(os.Environ(), b.env.Load()...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	runErr := cmd.Run()
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run go build: %w", err)
+	diagnostics := ParseDiagnostics(output.String(), workDir, findModuleRoot(workDir))
+	logDiagnostics("compiler", output.String(), diagnostics)
+
+	if runErr != nil {
+		return &BuildError{
+			Output:      output.String(),
+			Diagnostics: diagnostics,
+			err:         runErr,
+		}
 	}
 	return nil
 }
+
+// BuildError indicates that the program could not be built.
+type BuildError struct {
+
+	// Output holds the output that was produced by the compiler.
+	Output string
+
+	// Diagnostics holds the problems that were reported by the compiler.
+	Diagnostics []Diagnostic
+
+	err error
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("failed to run go build: %v", e.err)
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.err
+}
+
+// logDiagnostics logs the diagnostics in a compact form, one record per
+// problem, and any other output of the tool as is.
+func logDiagnostics(component, output string, diagnostics []Diagnostic) {
+	logger := slog.Default().With(logutil.ComponentKey, component)
+	for _, diagnostic := range diagnostics {
+		attrs := []any{
+			"file", diagnostic.File,
+			"line", diagnostic.Line,
+			"column", diagnostic.Column,
+		}
+		if diagnostic.Package != "" {
+			attrs = append([]any{"package", diagnostic.Package}, attrs...)
+		}
+		logger.Error(diagnostic.Message, attrs...)
+	}
+
+	otherWriter := logutil.ToWriter(logger)
+	for _, line := range strings.Split(output, "\n") {
+		if isDiagnosticOutput(line) {
+			continue
+		}
+		io.WriteString(otherWriter, line+"\n")
+	}
+}
+
+func isDiagnosticOutput(line string) bool {
+	return strings.HasPrefix(line, "\t") ||
+		packageHeaderPattern.MatchString(line) ||
+		diagnosticPattern.MatchString(line)
+}
+
+// findModuleRoot returns the closest directory, starting from dir, that
+// contains a go.mod file. If there is none, dir is returned.
+func findModuleRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, "go.mod")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
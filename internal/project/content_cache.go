@@ -0,0 +1,89 @@
+package project
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+)
+
+// ContentCache keeps the content digests of recently changed files, so
+// that writes which leave the content of a file unchanged can be detected.
+//
+// The cache holds up to a fixed number of files, evicting the least
+// recently updated ones, and is populated lazily as files change. It is not
+// safe for concurrent use.
+type ContentCache struct {
+	capacity    int
+	maxFileSize int64
+	entries     map[string]*list.Element
+	order       *list.List
+}
+
+type contentCacheEntry struct {
+	path   string
+	digest string
+}
+
+// NewContentCache creates a new ContentCache that holds the digests of up
+// to capacity files. Files that are larger than maxFileSize are not hashed
+// and are always considered changed.
+func NewContentCache(capacity int, maxFileSize int64) *ContentCache {
+	return &ContentCache{
+		capacity:    capacity,
+		maxFileSize: maxFileSize,
+		entries:     make(map[string]*list.Element, capacity),
+		order:       list.New(),
+	}
+}
+
+// Update calculates the digest of the specified file and returns whether
+// it differs from the cached one. Since the previous content of files that
+// are not cached is unknown, they are considered changed.
+func (c *ContentCache) Update(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		c.Remove(path)
+		return false, fmt.Errorf("failed to stat file %q: %w", path, err)
+	}
+	if info.IsDir() || info.Size() > c.maxFileSize {
+		c.Remove(path)
+		return true, nil
+	}
+	digest, err := CalculateContentDigest(path)
+	if err != nil {
+		c.Remove(path)
+		return false, err
+	}
+
+	if element, ok := c.entries[path]; ok {
+		entry := element.Value.(*contentCacheEntry)
+		changed := entry.digest != digest
+		entry.digest = digest
+		c.order.MoveToFront(element)
+		return changed, nil
+	}
+
+	c.entries[path] = c.order.PushFront(&contentCacheEntry{
+		path:   path,
+		digest: digest,
+	})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*contentCacheEntry).path)
+	}
+	return true, nil
+}
+
+// Remove drops the digest of the specified file from the cache.
+func (c *ContentCache) Remove(path string) {
+	if element, ok := c.entries[path]; ok {
+		c.order.Remove(element)
+		delete(c.entries, path)
+	}
+}
+
+// Len returns the number of files that are currently cached.
+func (c *ContentCache) Len() int {
+	return c.order.Len()
+}
@@ -0,0 +1,94 @@
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("ContentCache", func() {
+	var (
+		dir   string
+		cache *project.ContentCache
+	)
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+		return path
+	}
+
+	update := func(path string) bool {
+		changed, err := cache.Update(path)
+		Expect(err).ToNot(HaveOccurred())
+		return changed
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		cache = project.NewContentCache(2, 16)
+	})
+
+	It("considers files that are not cached changed", func() {
+		path := write("main.go", "package main")
+		Expect(update(path)).To(BeTrue())
+		Expect(cache.Len()).To(Equal(1))
+	})
+
+	It("detects writes with unchanged content", func() {
+		path := write("main.go", "package main")
+		update(path)
+		write("main.go", "package main")
+		Expect(update(path)).To(BeFalse())
+	})
+
+	It("detects writes with changed content", func() {
+		path := write("main.go", "package main")
+		update(path)
+		write("main.go", "package app")
+		Expect(update(path)).To(BeTrue())
+		write("main.go", "package app")
+		Expect(update(path)).To(BeFalse())
+	})
+
+	It("evicts the least recently updated files", func() {
+		first := write("first.go", "first")
+		second := write("second.go", "second")
+		third := write("third.go", "third")
+		update(first)
+		update(second)
+		update(first)
+		update(third)
+		Expect(cache.Len()).To(Equal(2))
+		Expect(update(first)).To(BeFalse())
+		Expect(update(second)).To(BeTrue())
+	})
+
+	It("does not cache large files", func() {
+		path := write("large.bin", "more than sixteen bytes")
+		Expect(update(path)).To(BeTrue())
+		Expect(update(path)).To(BeTrue())
+		Expect(cache.Len()).To(Equal(0))
+	})
+
+	It("reports missing files", func() {
+		path := write("main.go", "package main")
+		update(path)
+		Expect(os.Remove(path)).To(Succeed())
+		_, err := cache.Update(path)
+		Expect(err).To(HaveOccurred())
+		Expect(cache.Len()).To(Equal(0))
+	})
+
+	It("forgets removed files", func() {
+		path := write("main.go", "package main")
+		update(path)
+		cache.Remove(path)
+		Expect(cache.Len()).To(Equal(0))
+		Expect(update(path)).To(BeTrue())
+	})
+})
@@ -0,0 +1,115 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	packageHeaderPattern = regexp.MustCompile(`^# (\S+)`)
+	diagnosticPattern    = regexp.MustCompile(`^(.+?\.go):(\d+)(?::(\d+))?: (.*)$`)
+)
+
+// Diagnostic is a problem that was reported by the compiler.
+type Diagnostic struct {
+
+	// Package is the import path of the package that the problem was
+	// reported for. It is empty if the compiler did not specify one.
+	Package string
+
+	// File is the path of the affected file relative to the project root.
+	// Files outside the project root are specified with absolute paths.
+	File string
+
+	// Path is the absolute path of the affected file.
+	Path string
+
+	// Line is the line number of the problem.
+	Line int
+
+	// Column is the column of the problem or zero if the compiler did not
+	// specify one.
+	Column int
+
+	// Message describes the problem. Messages that span multiple lines
+	// are separated by newlines.
+	Message string
+}
+
+// Position returns the location of the problem in the file:line:column
+// format.
+func (d Diagnostic) Position() string {
+	if d.Column == 0 {
+		return fmt.Sprintf("%s:%d", d.File, d.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Position(), d.Message)
+}
+
+// ParseDiagnostics extracts the diagnostics from the output of go build.
+//
+// Relative paths in the output are resolved against workDir, which is the
+// directory in which the compiler was run, and are then made relative to
+// rootDir. Lines that are not diagnostics are ignored.
+func ParseDiagnostics(output, workDir, rootDir string) []Diagnostic {
+	var (
+		result     []Diagnostic
+		pkg        string
+		diagnostic *Diagnostic
+	)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		// The compiler indents additional information about the previous
+		// problem (e.g. the location of a conflicting declaration).
+		if strings.HasPrefix(line, "\t") {
+			if diagnostic != nil {
+				diagnostic.Message += "\n" + strings.TrimSpace(line)
+			}
+			continue
+		}
+		if diagnostic != nil {
+			result = append(result, *diagnostic)
+			diagnostic = nil
+		}
+
+		if match := packageHeaderPattern.FindStringSubmatch(line); match != nil {
+			pkg = match[1]
+			continue
+		}
+		if match := diagnosticPattern.FindStringSubmatch(line); match != nil {
+			lineNumber, _ := strconv.Atoi(match[2])
+			column, _ := strconv.Atoi(match[3])
+			path := match[1]
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(workDir, path)
+			}
+			diagnostic = &Diagnostic{
+				Package: pkg,
+				File:    relativeFile(rootDir, path),
+				Path:    path,
+				Line:    lineNumber,
+				Column:  column,
+				Message: match[4],
+			}
+		}
+	}
+	if diagnostic != nil {
+		result = append(result, *diagnostic)
+	}
+	return result
+}
+
+func relativeFile(rootDir, path string) string {
+	relPath, err := filepath.Rel(rootDir, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(relPath)
+}
@@ -0,0 +1,72 @@
+package project_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("ParseDiagnostics", func() {
+	const (
+		workDir = "/project/cmd/app"
+		rootDir = "/project"
+	)
+
+	It("parses diagnostics of multiple packages", func() {
+		output := "# example.com/app/internal/api\n" +
+			"../../internal/api/server.go:12:3: undefined: handler\n" +
+			"# example.com/app/cmd/app\n" +
+			"./main.go:9:6: main redeclared in this block\n" +
+			"\t./other.go:2:6: other declaration of main\n" +
+			"./main.go:15: missing return\n"
+
+		Expect(project.ParseDiagnostics(output, workDir, rootDir)).To(Equal([]project.Diagnostic{
+			{
+				Package: "example.com/app/internal/api",
+				File:    "internal/api/server.go",
+				Path:    "/project/internal/api/server.go",
+				Line:    12,
+				Column:  3,
+				Message: "undefined: handler",
+			},
+			{
+				Package: "example.com/app/cmd/app",
+				File:    "cmd/app/main.go",
+				Path:    "/project/cmd/app/main.go",
+				Line:    9,
+				Column:  6,
+				Message: "main redeclared in this block\n./other.go:2:6: other declaration of main",
+			},
+			{
+				Package: "example.com/app/cmd/app",
+				File:    "cmd/app/main.go",
+				Path:    "/project/cmd/app/main.go",
+				Line:    15,
+				Message: "missing return",
+			},
+		}))
+	})
+
+	It("keeps absolute paths outside of the project root", func() {
+		output := "/go/pkg/mod/example.com/lib@v1.0.0/lib.go:1:1: expected 'package', found 'EOF'\n"
+		diagnostics := project.ParseDiagnostics(output, workDir, rootDir)
+		Expect(diagnostics).To(HaveLen(1))
+		Expect(diagnostics[0].File).To(Equal("/go/pkg/mod/example.com/lib@v1.0.0/lib.go"))
+		Expect(diagnostics[0].Package).To(BeEmpty())
+	})
+
+	It("ignores output that is not a diagnostic", func() {
+		output := "go: downloading example.com/lib v1.0.0\n" +
+			"go: example.com/lib@v1.0.0: missing go.sum entry\n"
+		Expect(project.ParseDiagnostics(output, workDir, rootDir)).To(BeEmpty())
+	})
+
+	It("formats diagnostics in a compact form", func() {
+		diagnostic := project.Diagnostic{File: "main.go", Line: 9, Column: 6, Message: "undefined: x"}
+		Expect(diagnostic.String()).To(Equal("main.go:9:6: undefined: x"))
+
+		diagnostic.Column = 0
+		Expect(diagnostic.String()).To(Equal("main.go:9: undefined: x"))
+	})
+})
@@ -1,9 +1,13 @@
 package project
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+
+	"github.com/mokiat/gocrane/internal/filesystem"
 )
 
 // OpenDigestFile reads the digest string from the specified file.
@@ -30,9 +34,44 @@ func WriteFileDigest(out io.Writer, file string) error {
 	if err != nil {
 		return fmt.Errorf("failed to state file %q: %w", file, err)
 	}
+	writeFileInfoDigest(out, file, stat)
+	return nil
+}
+
+// WriteFilesDigest writes the digests of the specified files to the
+// specified Writer, in the order of the files. The output is the same as
+// calling WriteFileDigest for each file, but the files are inspected
+// concurrently.
+func WriteFilesDigest(out io.Writer, files []string) error {
+	stats, errs := filesystem.StatAll(files, os.Stat)
+	for i, file := range files {
+		if errs[i] != nil {
+			return fmt.Errorf("failed to state file %q: %w", file, errs[i])
+		}
+		writeFileInfoDigest(out, file, stats[i])
+	}
+	return nil
+}
+
+func writeFileInfoDigest(out io.Writer, file string, stat fs.FileInfo) {
 	// Note: Don't include millisecond precision, as that seems to differ between
 	// host and client machine (in some cases it is not included).
 	const timeFormat = "2006/01/02 15:04:05"
 	fmt.Fprint(out, len(file), file, stat.ModTime().UTC().Format(timeFormat), stat.Size())
-	return nil
+}
+
+// CalculateContentDigest returns a SHA-256 digest of the contents of the
+// specified file.
+func CalculateContentDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %q: %w", path, err)
+	}
+	defer file.Close()
+
+	dig := sha256.New()
+	if _, err := io.Copy(dig, file); err != nil {
+		return "", fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+	return fmt.Sprintf("%x", dig.Sum(nil)), nil
 }
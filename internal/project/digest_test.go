@@ -0,0 +1,43 @@
+package project_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("Digest", func() {
+	var files []string
+
+	BeforeEach(func() {
+		dir := GinkgoT().TempDir()
+		files = nil
+		for i := range 50 {
+			path := filepath.Join(dir, fmt.Sprintf("file%d.go", i))
+			Expect(os.WriteFile(path, bytes.Repeat([]byte("x"), i), 0o644)).To(Succeed())
+			files = append(files, path)
+		}
+	})
+
+	It("writes the same digest for many files as for each file", func() {
+		var expected bytes.Buffer
+		for _, file := range files {
+			Expect(project.WriteFileDigest(&expected, file)).To(Succeed())
+		}
+		var actual bytes.Buffer
+		Expect(project.WriteFilesDigest(&actual, files)).To(Succeed())
+		Expect(actual.String()).To(Equal(expected.String()))
+	})
+
+	It("reports missing files", func() {
+		Expect(os.Remove(files[10])).To(Succeed())
+		var out bytes.Buffer
+		Expect(project.WriteFilesDigest(&out, files)).To(MatchError(os.ErrNotExist))
+	})
+})
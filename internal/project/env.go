@@ -0,0 +1,231 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// NewEnvironment creates a new Environment that consists of the specified
+// KEY=VALUE variables and the variables in the specified dotenv files.
+func NewEnvironment(vars, files []string) *Environment {
+	return &Environment{
+		vars:  vars,
+		files: files,
+	}
+}
+
+// Environment describes the environment variables that are passed to the
+// program in addition to the environment of the current process.
+type Environment struct {
+	vars  []string
+	files []string
+}
+
+// Load returns the environment variables in KEY=VALUE form. Files are read
+// anew on each call, so that changes to them are picked up on restart.
+// Variables that are specified directly take precedence over variables
+// from files and later files take precedence over earlier ones.
+//
+// Files that cannot be read or parsed are reported and skipped, so that a
+// typo does not prevent the program from starting.
+func (e *Environment) Load() []string {
+	var result []string
+	lookup := func(key string) (string, bool) {
+		for i := len(result) - 1; i >= 0; i-- {
+			if name, value, _ := strings.Cut(result[i], "="); name == key {
+				return value, true
+			}
+		}
+		return os.LookupEnv(key)
+	}
+	for _, path := range e.files {
+		vars, err := readEnvFile(path, lookup)
+		if err != nil {
+			slog.Error("Failed to load environment file.", "path", path, "error", err)
+			continue
+		}
+		result = append(result, vars...)
+	}
+	return append(result, e.vars...)
+}
+
+func readEnvFile(path string, lookup func(string) (string, bool)) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	return ParseEnv(file, lookup)
+}
+
+// ParseEnv parses variables in the dotenv format and returns them in
+// KEY=VALUE form.
+//
+// Each line holds a KEY=VALUE pair, optionally preceded by "export".
+// Empty lines and lines starting with # are ignored. Values can be
+// unquoted, in which case a # preceded by whitespace starts a comment,
+// single-quoted, in which case they are taken literally, or double-quoted,
+// in which case the \n, \t, \", \\ and \$ escape sequences are supported.
+//
+// References to other variables in the form of $KEY, ${KEY} or
+// ${KEY:-default} are expanded in unquoted and double-quoted values. They
+// are resolved against the variables that were defined earlier in the
+// input and then against the specified lookup function.
+func ParseEnv(in io.Reader, lookup func(string) (string, bool)) ([]string, error) {
+	var (
+		result  []string
+		defined = make(map[string]string)
+	)
+	resolve := func(key string) (string, bool) {
+		if value, ok := defined[key]; ok {
+			return value, true
+		}
+		if lookup != nil {
+			return lookup(key)
+		}
+		return "", false
+	}
+
+	scanner := bufio.NewScanner(in)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, rawValue, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !isValidEnvKey(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(rawValue), resolve)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		defined[key] = value
+		result = append(result, key+"="+value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	return result, nil
+}
+
+func parseEnvValue(raw string, resolve func(string) (string, bool)) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, "'"):
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+
+	case strings.HasPrefix(raw, `"`):
+		var builder strings.Builder
+		for i := 1; i < len(raw); i++ {
+			switch raw[i] {
+			case '"':
+				return builder.String(), nil
+			case '\\':
+				if i+1 < len(raw) {
+					i++
+					builder.WriteString(unescapeEnvChar(raw[i]))
+				}
+			case '$':
+				value, size := expandEnvReference(raw[i:], resolve)
+				builder.WriteString(value)
+				i += size - 1
+			default:
+				builder.WriteByte(raw[i])
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+
+	default:
+		if index := strings.Index(raw, " #"); index >= 0 {
+			raw = strings.TrimSpace(raw[:index])
+		}
+		var builder strings.Builder
+		for i := 0; i < len(raw); i++ {
+			if raw[i] == '$' {
+				value, size := expandEnvReference(raw[i:], resolve)
+				builder.WriteString(value)
+				i += size - 1
+				continue
+			}
+			builder.WriteByte(raw[i])
+		}
+		return builder.String(), nil
+	}
+}
+
+// expandEnvReference expands the variable reference at the start of the
+// specified text, which starts with a $ sign. It returns the value and the
+// number of bytes that the reference occupies.
+func expandEnvReference(text string, resolve func(string) (string, bool)) (string, int) {
+	if strings.HasPrefix(text, "${") {
+		end := strings.Index(text, "}")
+		if end < 0 {
+			return text[:1], 1
+		}
+		expression := text[2:end]
+		key, fallback, hasFallback := strings.Cut(expression, ":-")
+		value, ok := resolve(key)
+		if hasFallback && (!ok || value == "") {
+			value = fallback
+		}
+		return value, end + 1
+	}
+	size := 1
+	for size < len(text) && isEnvKeyChar(text[size], size == 1) {
+		size++
+	}
+	if size == 1 {
+		return text[:1], 1
+	}
+	value, _ := resolve(text[1:size])
+	return value, size
+}
+
+func unescapeEnvChar(char byte) string {
+	switch char {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	case 'r':
+		return "\r"
+	default:
+		return string(char)
+	}
+}
+
+func isValidEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if !isEnvKeyChar(key[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isEnvKeyChar(char byte, first bool) bool {
+	switch {
+	case char == '_':
+		return true
+	case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z':
+		return true
+	case char >= '0' && char <= '9':
+		return !first
+	default:
+		return false
+	}
+}
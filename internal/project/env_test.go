@@ -0,0 +1,109 @@
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("ParseEnv", func() {
+	lookup := func(key string) (string, bool) {
+		switch key {
+		case "HOME":
+			return "/home/user", true
+		case "EMPTY":
+			return "", true
+		default:
+			return "", false
+		}
+	}
+
+	parse := func(input string) []string {
+		result, err := project.ParseEnv(strings.NewReader(input), lookup)
+		Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+
+	It("parses simple assignments", func() {
+		Expect(parse("# comment\n\nFIRST=one\nexport SECOND = two \n")).To(Equal([]string{
+			"FIRST=one",
+			"SECOND=two",
+		}))
+	})
+
+	It("strips inline comments from unquoted values", func() {
+		Expect(parse("KEY=value # comment\nHASH=a#b\n")).To(Equal([]string{
+			"KEY=value",
+			"HASH=a#b",
+		}))
+	})
+
+	It("takes single-quoted values literally", func() {
+		Expect(parse(`KEY='$HOME # not a comment \n'`)).To(Equal([]string{
+			`KEY=$HOME # not a comment \n`,
+		}))
+	})
+
+	It("supports escapes in double-quoted values", func() {
+		Expect(parse(`KEY="line\nnext \"quoted\" \$HOME"`)).To(Equal([]string{
+			"KEY=line\nnext \"quoted\" $HOME",
+		}))
+	})
+
+	It("expands references", func() {
+		Expect(parse("DIR=$HOME/app\nDATA=\"${DIR}/data\"\nMISSING=[$UNKNOWN]\nPRICE=5$\n")).To(Equal([]string{
+			"DIR=/home/user/app",
+			"DATA=/home/user/app/data",
+			"MISSING=[]",
+			"PRICE=5$",
+		}))
+	})
+
+	It("supports default values", func() {
+		Expect(parse("A=${UNKNOWN:-fallback}\nB=${EMPTY:-fallback}\nC=${HOME:-fallback}\n")).To(Equal([]string{
+			"A=fallback",
+			"B=fallback",
+			"C=/home/user",
+		}))
+	})
+
+	It("reports malformed lines", func() {
+		_, err := project.ParseEnv(strings.NewReader("GOOD=1\nnot an assignment\n"), lookup)
+		Expect(err).To(MatchError(ContainSubstring("line 2")))
+
+		_, err = project.ParseEnv(strings.NewReader(`KEY="unterminated`), lookup)
+		Expect(err).To(MatchError(ContainSubstring("unterminated")))
+	})
+})
+
+var _ = Describe("Environment", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "first.env"), []byte("A=1\nB=2\n"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "second.env"), []byte("B=${A}0\n"), 0o644)).To(Succeed())
+	})
+
+	It("combines files and variables in order of precedence", func() {
+		env := project.NewEnvironment([]string{"A=override"}, []string{
+			filepath.Join(dir, "first.env"),
+			filepath.Join(dir, "missing.env"),
+			filepath.Join(dir, "second.env"),
+		})
+		Expect(env.Load()).To(Equal([]string{"A=1", "B=2", "B=10", "A=override"}))
+	})
+
+	It("reloads files", func() {
+		env := project.NewEnvironment(nil, []string{filepath.Join(dir, "first.env")})
+		Expect(env.Load()).To(Equal([]string{"A=1", "B=2"}))
+
+		Expect(os.WriteFile(filepath.Join(dir, "first.env"), []byte("A=3\n"), 0o644)).To(Succeed())
+		Expect(env.Load()).To(Equal([]string{"A=3"}))
+	})
+})
@@ -0,0 +1,69 @@
+package project
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// NewInputRelay creates a new InputRelay that reads from the specified
+// reader.
+func NewInputRelay(in io.Reader) *InputRelay {
+	return &InputRelay{
+		in: bufio.NewReader(in),
+	}
+}
+
+// InputRelay reads the input of GoCrane and forwards it to the currently
+// running program. All input should be read through a single InputRelay,
+// since it buffers what it reads.
+//
+// Since programs get restarted, the relay outlives them and is reconnected
+// to each newly started program. Input that is received while no program
+// is running is discarded.
+type InputRelay struct {
+	in *bufio.Reader
+
+	mu     sync.Mutex
+	target io.Writer
+}
+
+// ReadLine blocks until a complete line of input is available and returns
+// it, including the line terminator.
+func (r *InputRelay) ReadLine() (string, error) {
+	return r.in.ReadString('\n')
+}
+
+// ReadKey blocks until a single byte of input (e.g. a key press when the
+// terminal is in key input mode) is available and returns it.
+func (r *InputRelay) ReadKey() (byte, error) {
+	return r.in.ReadByte()
+}
+
+// Forward writes the specified input to the currently attached program.
+func (r *InputRelay) Forward(input string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.target == nil {
+		slog.Debug("Discarding input, as there is no running program.")
+		return
+	}
+	if _, err := io.WriteString(r.target, input); err != nil {
+		slog.Debug("Failed to forward input to program.", "error", err)
+	}
+}
+
+func (r *InputRelay) attach(target io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.target = target
+}
+
+func (r *InputRelay) detach(target io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.target == target {
+		r.target = nil
+	}
+}
@@ -9,8 +9,11 @@ import (
 // Analyze traverses the folders specified by rootDirs and evaluates which
 // files and folders would be watched based on the specified filters.
 //
+// Symlinked directories are descended into only if followSymlinks is set.
+// If dirCache is not nil, directory listings are read through it.
+//
 // The outcome of the analysis is returned as a Summary.
-func Analyze(rootDirs []filesystem.AbsolutePath, watchFilter, sourceFilter, resourceFilter *filesystem.FilterTree) *Summary {
+func Analyze(rootDirs []filesystem.AbsolutePath, watchFilter, sourceFilter, resourceFilter *filesystem.FilterTree, followSymlinks bool, dirCache *filesystem.DirCache) *Summary {
 	var (
 		errored = make(map[string]error)
 		omitted = make(map[string]struct{})
@@ -22,8 +25,19 @@ func Analyze(rootDirs []filesystem.AbsolutePath, watchFilter, sourceFilter, reso
 		watchedResourceFiles = make(map[filesystem.AbsolutePath]struct{})
 	)
 
+	traverse := filesystem.Traverse
+	if followSymlinks {
+		traverse = filesystem.TraverseSymlinks
+	}
+	if dirCache != nil {
+		traverse = dirCache.Traverse
+		if followSymlinks {
+			traverse = dirCache.TraverseSymlinks
+		}
+	}
+
 	for _, root := range rootDirs {
-		filesystem.Traverse(root, func(p string, isDir bool, err error) error {
+		traverse(root, func(p string, isDir bool, err error) error {
 			if err != nil {
 				errored[p] = fmt.Errorf("error traversing path: %w", err)
 				return filesystem.ErrSkip
@@ -0,0 +1,140 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/mod/modfile"
+)
+
+// LocalModuleDirs returns the directories of the local modules that the
+// main module in mainDir depends on. These are the modules that are used
+// by the go.work file of the workspace, if there is one, and the local
+// targets of replace directives in the go.work file and in the go.mod files
+// of the main modules.
+//
+// The directory of the main module itself is not included. The returned
+// directories are absolute and sorted.
+func LocalModuleDirs(mainDir string) ([]string, error) {
+	absMainDir, err := filepath.Abs(mainDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute directory for %q: %w", mainDir, err)
+	}
+	moduleRoot := findModuleRoot(absMainDir)
+
+	var result []string
+	addDir := func(dir string) {
+		if dir != moduleRoot && !slices.Contains(result, dir) {
+			result = append(result, dir)
+		}
+	}
+
+	mainModuleDirs := []string{moduleRoot}
+	workFile, err := findWorkFile(absMainDir)
+	if err != nil {
+		return nil, err
+	}
+	if workFile != "" {
+		work, err := parseWorkFile(workFile)
+		if err != nil {
+			return nil, err
+		}
+		workDir := filepath.Dir(workFile)
+		for _, use := range work.Use {
+			dir := resolveModuleDir(workDir, use.Path)
+			addDir(dir)
+			if !slices.Contains(mainModuleDirs, dir) {
+				mainModuleDirs = append(mainModuleDirs, dir)
+			}
+		}
+		for _, replace := range work.Replace {
+			if isLocalReplace(replace) {
+				addDir(resolveModuleDir(workDir, replace.New.Path))
+			}
+		}
+	}
+
+	for _, dir := range mainModuleDirs {
+		replaces, err := parseModReplaces(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		for _, replace := range replaces {
+			if isLocalReplace(replace) {
+				addDir(resolveModuleDir(dir, replace.New.Path))
+			}
+		}
+	}
+
+	slices.Sort(result)
+	return result, nil
+}
+
+// findWorkFile returns the path of the go.work file that applies to the
+// specified directory or an empty string if workspace mode is not used.
+// Similar to the go command, the GOWORK environment variable takes
+// precedence over searching the parent directories.
+func findWorkFile(dir string) (string, error) {
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return "", nil
+	case "":
+		for current := dir; ; {
+			candidate := filepath.Join(current, "go.work")
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+			parent := filepath.Dir(current)
+			if parent == current {
+				return "", nil
+			}
+			current = parent
+		}
+	default:
+		absWork, err := filepath.Abs(gowork)
+		if err != nil {
+			return "", fmt.Errorf("failed to get absolute path for %q: %w", gowork, err)
+		}
+		return absWork, nil
+	}
+}
+
+func parseWorkFile(path string) (*modfile.WorkFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+	work, err := modfile.ParseWork(path, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file %q: %w", path, err)
+	}
+	return work, nil
+}
+
+func parseModReplaces(path string) ([]*modfile.Replace, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+	mod, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file %q: %w", path, err)
+	}
+	return mod.Replace, nil
+}
+
+func isLocalReplace(replace *modfile.Replace) bool {
+	return replace.New.Version == "" && modfile.IsDirectoryPath(replace.New.Path)
+}
+
+func resolveModuleDir(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(baseDir, filepath.FromSlash(path))
+}
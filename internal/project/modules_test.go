@@ -0,0 +1,68 @@
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mokiat/gocrane/internal/project"
+)
+
+var _ = Describe("LocalModuleDirs", func() {
+	var rootDir string
+
+	writeFile := func(path, content string) {
+		fullPath := filepath.Join(rootDir, path)
+		Expect(os.MkdirAll(filepath.Dir(fullPath), 0o755)).To(Succeed())
+		Expect(os.WriteFile(fullPath, []byte(content), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		rootDir = GinkgoT().TempDir()
+		GinkgoT().Setenv("GOWORK", "")
+	})
+
+	It("returns local replace targets of the main module", func() {
+		writeFile("service/go.mod", "module example.com/service\n\n"+
+			"go 1.22\n\n"+
+			"replace example.com/shared => ../shared\n"+
+			"replace example.com/remote => example.com/fork v1.0.0\n"+
+			"replace example.com/abs => "+filepath.Join(rootDir, "abs")+"\n")
+		writeFile("service/cmd/app/main.go", "package main\n")
+
+		Expect(project.LocalModuleDirs(filepath.Join(rootDir, "service", "cmd", "app"))).To(Equal([]string{
+			filepath.Join(rootDir, "abs"),
+			filepath.Join(rootDir, "shared"),
+		}))
+	})
+
+	It("returns modules of the workspace and their replace targets", func() {
+		writeFile("go.work", "go 1.22\n\n"+
+			"use (\n\t./service\n\t./lib\n)\n\n"+
+			"replace example.com/tool => ./tools/tool\n")
+		writeFile("service/go.mod", "module example.com/service\n\ngo 1.22\n")
+		writeFile("lib/go.mod", "module example.com/lib\n\ngo 1.22\n\nreplace example.com/util => ../util\n")
+
+		Expect(project.LocalModuleDirs(filepath.Join(rootDir, "service"))).To(Equal([]string{
+			filepath.Join(rootDir, "lib"),
+			filepath.Join(rootDir, "tools", "tool"),
+			filepath.Join(rootDir, "util"),
+		}))
+	})
+
+	It("ignores the workspace when it is disabled", func() {
+		GinkgoT().Setenv("GOWORK", "off")
+		writeFile("go.work", "go 1.22\n\nuse ./lib\n")
+		writeFile("service/go.mod", "module example.com/service\n\ngo 1.22\n")
+
+		Expect(project.LocalModuleDirs(filepath.Join(rootDir, "service"))).To(BeEmpty())
+	})
+
+	It("reports malformed files", func() {
+		writeFile("service/go.mod", "module example.com/service\n\nreplace =>\n")
+		_, err := project.LocalModuleDirs(filepath.Join(rootDir, "service"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -2,45 +2,182 @@ package project
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"syscall"
+	"time"
 
-	"github.com/mokiat/gocrane/internal/logutil"
+	"github.com/mokiat/gocrane/internal/terminal"
 )
 
-func NewRunner(args []string) *Runner {
+// outputWaitDelay is the amount of time to wait for the output of a program
+// to be drained after it has exited. This covers the case where the program
+// has started child processes that hold on to its output.
+const outputWaitDelay = time.Second
+
+// NewRunner creates a new Runner that starts programs with the specified
+// arguments and environment and writes their standard output and standard
+// error to the specified writers.
+//
+// If usePTY is true, programs are attached to a pseudo-terminal that
+// inherits the window size of the standard output of the current process.
+// In this case, both the standard output and the standard error of the
+// program are written to stdout.
+//
+// If input is not nil, it is connected to the standard input of each
+// started program.
+func NewRunner(args []string, env *Environment, stdout, stderr io.Writer, usePTY bool, input *InputRelay) *Runner {
 	return &Runner{
-		args: args,
+		args:   args,
+		env:    env,
+		stdout: stdout,
+		stderr: stderr,
+		usePTY: usePTY,
+		input:  input,
 	}
 }
 
 type Runner struct {
-	args []string
+	args   []string
+	env    *Environment
+	stdout io.Writer
+	stderr io.Writer
+	usePTY bool
+	input  *InputRelay
 }
 
-func (r *Runner) Run(ctx context.Context, path string) (*Process, error) {
-	logger := log.New(log.Writer(), "[program]: ", log.Ltime|log.Lmsgprefix)
-
+// Run starts the program at the specified path. The specified extra
+// environment variables take precedence over all other variables.
+func (r *Runner) Run(ctx context.Context, path string, extraEnv []string) (*Process, error) {
 	runCtx, killFunc := context.WithCancel(ctx)
 	cmd := exec.CommandContext(runCtx, path, r.args...)
-	cmd.Stdout = logutil.ToWriter(logger)
-	cmd.Stderr = logutil.ToWriter(logger)
+	cmd.Env = append(os.Environ(), r.env.Load()...)
+	cmd.Env = append(cmd.Env, extraEnv...)
+	cmd.Stdout = r.stdout
+	cmd.Stderr = r.stderr
+	cmd.WaitDelay = outputWaitDelay
+
+	var pty *terminal.PTY
+	if r.usePTY {
+		var err error
+		if pty, err = terminal.OpenPTY(); err != nil {
+			killFunc()
+			return nil, fmt.Errorf("failed to allocate pseudo-terminal: %w", err)
+		}
+		pty.Attach(cmd)
+		if err := pty.InheritSize(os.Stdout); err != nil {
+			slog.Debug("Failed to apply window size to pseudo-terminal.", "error", err)
+		}
+	}
+
+	var stdin io.Writer
+	switch {
+	case r.input == nil:
+	case pty != nil:
+		stdin = pty
+	default:
+		pipe, err := cmd.StdinPipe()
+		if err != nil {
+			killFunc()
+			return nil, fmt.Errorf("failed to create input pipe: %w", err)
+		}
+		stdin = pipe
+	}
+
 	if err := cmd.Start(); err != nil {
 		killFunc() // otherwise linter complains
+		if pty != nil {
+			pty.Close()
+		}
 		return nil, fmt.Errorf("failed to start program: %w", err)
 	}
-	return &Process{
-		process: cmd.Process,
-		kill:    killFunc,
-	}, nil
+
+	process := &Process{
+		cmd:  cmd,
+		kill: killFunc,
+		release: func() {
+			flushOutput(r.stdout)
+			flushOutput(r.stderr)
+		},
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(process.done)
+		process.waitErr = cmd.Wait()
+	}()
+	if pty != nil {
+		process.release = r.forwardPTY(pty, process.release)
+	}
+	if stdin != nil {
+		r.input.attach(stdin)
+		release := process.release
+		process.release = func() {
+			r.input.detach(stdin)
+			release()
+		}
+	}
+	return process, nil
+}
+
+// forwardPTY copies the output of the pseudo-terminal to the standard output
+// writer. It returns a function that releases the pseudo-terminal and then
+// calls the specified release function.
+func (r *Runner) forwardPTY(pty *terminal.PTY, release func()) func() {
+	if err := pty.ReleaseSlave(); err != nil {
+		slog.Debug("Failed to release pseudo-terminal slave.", "error", err)
+	}
+	stopForwardingSize := pty.ForwardSize(os.Stdout)
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		io.Copy(r.stdout, pty)
+	}()
+	return func() {
+		stopForwardingSize()
+		select {
+		case <-outputDone:
+		case <-time.After(outputWaitDelay):
+			slog.Warn("Program output was not fully drained.")
+		}
+		pty.Close()
+		release()
+	}
 }
 
 type Process struct {
-	process *os.Process
+	cmd     *exec.Cmd
 	kill    func()
+	release func()
+
+	done    chan struct{}
+	waitErr error
+}
+
+// PID returns the process ID of the program.
+func (p *Process) PID() int {
+	return p.cmd.Process.Pid
+}
+
+// Exited returns whether the program has exited, either on its own or
+// because it was stopped.
+func (p *Process) Exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExitCode returns the exit code of the program or -1 if the program was
+// terminated by a signal. It should only be called once the program has
+// exited.
+func (p *Process) ExitCode() int {
+	return p.cmd.ProcessState.ExitCode()
 }
 
 func (p *Process) Stop(ctx context.Context) error {
@@ -50,21 +187,37 @@ func (p *Process) Stop(ctx context.Context) error {
 	go func() {
 		select {
 		case <-ctx.Done():
-			log.Println("Killing program, as it failed to shutdown gracefully...")
+			slog.Warn("Killing program, as it failed to shutdown gracefully...", "pid", p.PID())
 			p.kill()
 		case <-stopped:
 		}
 	}()
 
-	if err := p.process.Signal(syscall.SIGTERM); err != nil {
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
 		return fmt.Errorf("failed to send sigterm signal to program: %w", err)
 	}
-	state, err := p.process.Wait()
-	if err != nil {
+	<-p.done
+	defer p.release()
+
+	err := p.waitErr
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.Is(err, exec.ErrWaitDelay):
+		slog.Warn("Program output was not fully drained.", "pid", p.PID())
+	case errors.As(err, &exitErr):
+		slog.Warn("Program exited with non-zero exit code.", "pid", p.PID(), "exit_code", exitErr.ExitCode())
+	default:
 		return fmt.Errorf("failed to wait for program to stop: %w", err)
 	}
-	if !state.Success() {
-		log.Printf("Program exited with non-zero exit code: %d", state.ExitCode())
-	}
 	return nil
 }
+
+func flushOutput(out io.Writer) {
+	if flusher, ok := out.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			slog.Error("Failed to flush program output.", "error", err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+//go:build linux || darwin
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// EnableKeyInput switches the specified terminal to a mode where each key
+// press is made available immediately and is not echoed back. Unlike raw
+// mode, output processing and signal generation (e.g. Ctrl+C) are retained.
+//
+// The returned function restores the previous mode of the terminal.
+func EnableKeyInput(file *os.File) (func() error, error) {
+	fd := int(file.Fd())
+	original, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get terminal mode: %w", err)
+	}
+	modified := *original
+	modified.Lflag &^= unix.ICANON | unix.ECHO
+	modified.Cc[unix.VMIN] = 1
+	modified.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &modified); err != nil {
+		return nil, fmt.Errorf("failed to set terminal mode: %w", err)
+	}
+	return func() error {
+		return unix.IoctlSetTermios(fd, ioctlSetTermios, original)
+	}, nil
+}
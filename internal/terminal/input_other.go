@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// EnableKeyInput switches the specified terminal to a mode where each key
+// press is made available immediately and is not echoed back.
+//
+// This is only supported on Linux and MacOS.
+func EnableKeyInput(file *os.File) (func() error, error) {
+	return nil, fmt.Errorf("key input is not supported: %w", errors.ErrUnsupported)
+}
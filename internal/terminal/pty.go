@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"errors"
+	"os"
+)
+
+// PTY is a pseudo-terminal pair.
+//
+// A program is attached to the slave side of the pseudo-terminal, so that it
+// behaves as though it were running in a terminal, while its output can be
+// read from the master side.
+type PTY struct {
+	master *os.File
+	slave  *os.File
+}
+
+// Read reads output that was written to the slave side of the
+// pseudo-terminal.
+//
+// Once the slave side has been released and all programs attached to it
+// have exited, Read returns an error.
+func (p *PTY) Read(data []byte) (int, error) {
+	return p.master.Read(data)
+}
+
+// Write writes input to the slave side of the pseudo-terminal.
+func (p *PTY) Write(data []byte) (int, error) {
+	return p.master.Write(data)
+}
+
+// ReleaseSlave closes the slave side of the pseudo-terminal in the current
+// process. It should be called once the program attached to it has started.
+func (p *PTY) ReleaseSlave() error {
+	if p.slave == nil {
+		return nil
+	}
+	err := p.slave.Close()
+	p.slave = nil
+	return err
+}
+
+// Close releases all resources held by the pseudo-terminal.
+func (p *PTY) Close() error {
+	return errors.Join(p.ReleaseSlave(), p.master.Close())
+}
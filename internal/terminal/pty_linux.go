@@ -0,0 +1,109 @@
+//go:build linux
+
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// OpenPTY allocates a new pseudo-terminal pair.
+func OpenPTY() (*PTY, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pseudo-terminal master: %w", err)
+	}
+	var (
+		index  int
+		ctlErr error
+	)
+	rawConn, err := master.SyscallConn()
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to access pseudo-terminal master: %w", err)
+	}
+	err = rawConn.Control(func(fd uintptr) {
+		if ctlErr = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); ctlErr != nil {
+			return
+		}
+		index, ctlErr = unix.IoctlGetInt(int(fd), unix.TIOCGPTN)
+	})
+	if err = errors.Join(err, ctlErr); err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to unlock pseudo-terminal: %w", err)
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", index), os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to open pseudo-terminal slave: %w", err)
+	}
+	return &PTY{
+		master: master,
+		slave:  slave,
+	}, nil
+}
+
+// Attach configures the specified command to use the slave side of the
+// pseudo-terminal as its controlling terminal and for all of its standard
+// streams.
+func (p *PTY) Attach(cmd *exec.Cmd) {
+	cmd.Stdin = p.slave
+	cmd.Stdout = p.slave
+	cmd.Stderr = p.slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0 // refers to Stdin in the child process
+}
+
+// InheritSize applies the window size of the specified terminal to the
+// pseudo-terminal.
+func (p *PTY) InheritSize(from *os.File) error {
+	size, err := unix.IoctlGetWinsize(int(from.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return fmt.Errorf("failed to get window size: %w", err)
+	}
+	rawConn, err := p.master.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("failed to access pseudo-terminal master: %w", err)
+	}
+	var ctlErr error
+	err = rawConn.Control(func(fd uintptr) {
+		ctlErr = unix.IoctlSetWinsize(int(fd), unix.TIOCSWINSZ, size)
+	})
+	if err = errors.Join(err, ctlErr); err != nil {
+		return fmt.Errorf("failed to set window size: %w", err)
+	}
+	return nil
+}
+
+// ForwardSize applies the window size of the specified terminal to the
+// pseudo-terminal each time the former is resized, until the returned
+// function is called.
+func (p *PTY) ForwardSize(from *os.File) func() {
+	resized := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(resized, syscall.SIGWINCH)
+	go func() {
+		for {
+			select {
+			case <-resized:
+				p.InheritSize(from)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(resized)
+		close(done)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !linux
+
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// OpenPTY allocates a new pseudo-terminal pair.
+//
+// Pseudo-terminals are only supported on Linux.
+func OpenPTY() (*PTY, error) {
+	return nil, fmt.Errorf("pseudo-terminals are not supported: %w", errors.ErrUnsupported)
+}
+
+// Attach configures the specified command to use the slave side of the
+// pseudo-terminal as its controlling terminal and for all of its standard
+// streams.
+func (p *PTY) Attach(cmd *exec.Cmd) {}
+
+// InheritSize applies the window size of the specified terminal to the
+// pseudo-terminal.
+func (p *PTY) InheritSize(from *os.File) error {
+	return errors.ErrUnsupported
+}
+
+// ForwardSize applies the window size of the specified terminal to the
+// pseudo-terminal each time the former is resized, until the returned
+// function is called.
+func (p *PTY) ForwardSize(from *os.File) func() {
+	return func() {}
+}
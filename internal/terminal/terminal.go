@@ -0,0 +1,14 @@
+// Package terminal provides utilities for working with terminals and
+// pseudo-terminals.
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// IsTerminal returns whether the specified file is a terminal.
+func IsTerminal(file *os.File) bool {
+	return term.IsTerminal(int(file.Fd()))
+}
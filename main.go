@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,11 +10,11 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/mokiat/gocrane/internal/command"
+	"github.com/mokiat/gocrane/internal/logutil"
 )
 
 func main() {
-	log.SetPrefix("[gocrane]: ")
-	log.SetFlags(log.Ltime | log.Lmsgprefix)
+	slog.SetDefault(slog.New(logutil.NewHandler(os.Stderr, logutil.FormatText, slog.LevelInfo)))
 
 	app := &cli.App{
 		Name:  "gocrane",
@@ -23,12 +23,16 @@ func main() {
 		Commands: []*cli.Command{
 			command.Build(),
 			command.Run(),
+			command.Explain(),
+			command.Status(),
+			command.Trigger(),
 		},
 	}
 
 	appCtx, appStop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer appStop()
 	if err := app.RunContext(appCtx, os.Args); err != nil {
-		log.Fatalf("Crashed: %v", err)
+		slog.Error("Crashed", "error", err)
+		os.Exit(1)
 	}
 }